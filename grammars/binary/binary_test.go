@@ -0,0 +1,57 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline -bytes binary.peg
+
+package binary
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBinary(t *testing.T) {
+	buffer := []byte("MsgTdéjà vu\n\xff\x80\xfe\x00T\n")
+	p := &Binary[uint32]{Buffer: buffer}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	var texts []string
+	for _, token := range p.Tokens() {
+		if token.pegRule == rulePegText {
+			if token.end > uint32(len(buffer)) {
+				t.Fatalf("token ends at %d, after the end of the input", token.end)
+			}
+			texts = append(texts, string(buffer[token.begin:token.end]))
+		}
+	}
+	expected := []string{"déjà vu", "\x80\xfe", ""}
+	if len(texts) != len(expected) {
+		t.Fatalf("expected %q, got %q", expected, texts)
+	}
+	for i := range texts {
+		if texts[i] != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], texts[i])
+		}
+	}
+}
+
+func TestBinaryError(t *testing.T) {
+	p := &Binary[uint32]{Buffer: []byte("msgTé\n\xff\x80\x7f")}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	var parseError *ParseError
+	if err := p.Parse(); !errors.As(err, &parseError) {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if parseError.Offset != 9 || parseError.Line != 2 || parseError.Column != 3 {
+		t.Errorf("expected offset 9 at line 2 col 3, got offset %d at line %d col %d",
+			parseError.Offset, parseError.Line, parseError.Column)
+	}
+}
@@ -7,7 +7,9 @@
 package calculator
 
 import (
+	"errors"
 	"math/big"
+	"strings"
 	"testing"
 )
 
@@ -27,3 +29,34 @@ func TestCalculator(t *testing.T) {
 		t.Fatal("got incorrect result")
 	}
 }
+
+func TestMaxDepth(t *testing.T) {
+	nested := func(n int) string {
+		return strings.Repeat("(", n) + "1" + strings.Repeat(")", n)
+	}
+	calc := &Calculator[uint32]{Buffer: nested(100000)}
+	if err := calc.Init(MaxDepth[uint32](1000)); err != nil {
+		t.Fatal(err)
+	}
+	err := calc.Parse()
+	var depthError *DepthError
+	if !errors.As(err, &depthError) {
+		t.Fatalf("expected a *DepthError, got %v", err)
+	}
+	if depthError.Line != 1 || depthError.Column > 1000 || depthError.MaxDepth != 1000 {
+		t.Errorf("unexpected error %v", depthError)
+	}
+
+	calc = &Calculator[uint32]{Buffer: nested(100)}
+	if err := calc.Init(MaxDepth[uint32](1000)); err != nil {
+		t.Fatal(err)
+	}
+	calc.Expression.Init(calc.Buffer)
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	calc.Execute()
+	if calc.Evaluate().Cmp(big.NewInt(1)) != 0 {
+		t.Fatal("got incorrect result")
+	}
+}
@@ -8,128 +8,74 @@ import (
 	"math/big"
 )
 
-func (c *Calculator[_]) Eval() *big.Int {
-	return c.Rulee(c.AST())
+// evaluator computes the value of an expression while walking its syntax
+// tree, keeping the values of the subexpressions left to combine on a stack.
+type evaluator[U Uint] struct {
+	BaseVisitor[U]
+	buffer []rune
+	values []*big.Int
 }
 
-func (c *Calculator[U]) Rulee(node *node[U]) *big.Int {
-	node = node.up
-	for node != nil {
-		switch node.pegRule {
-		case rulee1:
-			return c.Rulee1(node)
-		}
-		node = node.next
+func (c *Calculator[U]) Eval() *big.Int {
+	e := &evaluator[U]{buffer: c.buffer}
+	Walk(c.AST(), e)
+	if len(e.values) != 1 {
+		return nil
 	}
-	return nil
+	return e.values[0]
+}
+
+func (e *evaluator[U]) ExitNumber(n *node[U]) {
+	text := n.up
+	a := big.NewInt(0)
+	a.SetString(string(e.buffer[text.begin:text.end]), 10)
+	e.values = append(e.values, a)
 }
 
-func (c *Calculator[U]) Rulee1(node *node[U]) *big.Int {
-	node = node.up
-	var a *big.Int
-	for node != nil {
-		switch node.pegRule {
-		case rulee2:
-			a = c.Rulee2(node)
+func (e *evaluator[U]) ExitE4(n *node[U]) {
+	if n.up.pegRule == ruleminus {
+		a := e.values[len(e.values)-1]
+		a.Neg(a)
+	}
+}
+
+func (e *evaluator[U]) ExitE1(n *node[U]) {
+	e.fold(n)
+}
+
+func (e *evaluator[U]) ExitE2(n *node[U]) {
+	e.fold(n)
+}
+
+func (e *evaluator[U]) ExitE3(n *node[U]) {
+	e.fold(n)
+}
+
+// fold replaces the values of the operands below n with the result of
+// applying the operators between them from left to right.
+func (e *evaluator[U]) fold(n *node[U]) {
+	var operators []pegRule
+	for operand := n.up; operand.next != nil; operand = operand.next.next {
+		operators = append(operators, operand.next.pegRule)
+	}
+	first := len(e.values) - len(operators) - 1
+	a := e.values[first]
+	for i, operator := range operators {
+		b := e.values[first+i+1]
+		switch operator {
 		case ruleadd:
-			node = node.next
-			b := c.Rulee2(node)
 			a.Add(a, b)
 		case ruleminus:
-			node = node.next
-			b := c.Rulee2(node)
 			a.Sub(a, b)
-		}
-		node = node.next
-	}
-	return a
-}
-
-func (c *Calculator[U]) Rulee2(node *node[U]) *big.Int {
-	node = node.up
-	var a *big.Int
-	for node != nil {
-		switch node.pegRule {
-		case rulee3:
-			a = c.Rulee3(node)
 		case rulemultiply:
-			node = node.next
-			b := c.Rulee3(node)
 			a.Mul(a, b)
 		case ruledivide:
-			node = node.next
-			b := c.Rulee3(node)
 			a.Div(a, b)
 		case rulemodulus:
-			node = node.next
-			b := c.Rulee3(node)
 			a.Mod(a, b)
-		}
-		node = node.next
-	}
-	return a
-}
-
-func (c *Calculator[U]) Rulee3(node *node[U]) *big.Int {
-	node = node.up
-	var a *big.Int
-	for node != nil {
-		switch node.pegRule {
-		case rulee4:
-			a = c.Rulee4(node)
 		case ruleexponentiation:
-			node = node.next
-			b := c.Rulee4(node)
 			a.Exp(a, b, nil)
 		}
-		node = node.next
-	}
-	return a
-}
-
-func (c *Calculator[U]) Rulee4(node *node[U]) *big.Int {
-	node = node.up
-	minus := false
-	for node != nil {
-		switch node.pegRule {
-		case rulevalue:
-			a := c.Rulevalue(node)
-			if minus {
-				a.Neg(a)
-			}
-			return a
-		case ruleminus:
-			minus = true
-		}
-		node = node.next
-	}
-	return nil
-}
-
-func (c *Calculator[U]) Rulevalue(node *node[U]) *big.Int {
-	node = node.up
-	for node != nil {
-		switch node.pegRule {
-		case rulenumber:
-			a := big.NewInt(0)
-			a.SetString(string(c.buffer[node.begin:node.end]), 10)
-			return a
-		case rulesub:
-			return c.Rulesub(node)
-		}
-		node = node.next
-	}
-	return nil
-}
-
-func (c *Calculator[U]) Rulesub(node *node[U]) *big.Int {
-	node = node.up
-	for node != nil {
-		switch node.pegRule {
-		case rulee1:
-			return c.Rulee1(node)
-		}
-		node = node.next
 	}
-	return nil
+	e.values = append(e.values[:first], a)
 }
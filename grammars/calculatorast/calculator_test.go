@@ -8,6 +8,7 @@ package calculatorast
 
 import (
 	"math/big"
+	"slices"
 	"testing"
 )
 
@@ -25,3 +26,56 @@ func TestCalculator(t *testing.T) {
 		t.Fatal("got incorrect result")
 	}
 }
+
+func TestCalculatorExpressions(t *testing.T) {
+	for expression, want := range map[string]int64{
+		"7":                 7,
+		"-7":                -7,
+		"1 - 2 - 3":         -4,
+		"2 ^ 3 ^ 2":         64,
+		"100 / 7 % 4 * 3":   6,
+		"-(2 + 3) * -(4)":   20,
+		"((1)) + (2 * (3))": 7,
+	} {
+		calc := &Calculator[uint32]{Buffer: expression}
+		if err := calc.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := calc.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		if got := calc.Eval(); got == nil || got.Cmp(big.NewInt(want)) != 0 {
+			t.Errorf("%q = %v, want %d", expression, got, want)
+		}
+	}
+}
+
+type recorder struct {
+	BaseVisitor[uint32]
+	calls []string
+}
+
+func (r *recorder) EnterE4(*node[uint32])    { r.calls = append(r.calls, "enter e4") }
+func (r *recorder) ExitE4(*node[uint32])     { r.calls = append(r.calls, "exit e4") }
+func (r *recorder) EnterMinus(*node[uint32]) { r.calls = append(r.calls, "enter minus") }
+func (r *recorder) ExitNumber(*node[uint32]) { r.calls = append(r.calls, "exit number") }
+
+func TestWalk(t *testing.T) {
+	calc := &Calculator[uint32]{Buffer: "-1-2"}
+	if err := calc.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{}
+	Walk(calc.AST(), r)
+	want := []string{
+		"enter e4", "enter minus", "exit number", "exit e4",
+		"enter minus",
+		"enter e4", "exit number", "exit e4",
+	}
+	if !slices.Equal(r.calls, want) {
+		t.Fatalf("got calls %q, want %q", r.calls, want)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package calculatorast_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/pointlander/peg/grammars/calculatorast"
+)
+
+// checkParents fails if a node below n doesn't have its parent as Parent.
+func checkParents(t *testing.T, n *calculatorast.Node[uint32]) {
+	t.Helper()
+	for child := range n.Children() {
+		if child.Parent() != n {
+			t.Fatalf("%v %q has parent %v", child.Rule(), child.Text(), child.Parent())
+		}
+		checkParents(t, child)
+	}
+}
+
+func TestNodeAPI(t *testing.T) {
+	buffer := "1 + (23*4)"
+	calc := &calculatorast.Calculator[uint32]{Buffer: buffer}
+	if err := calc.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	root := calc.AST()
+	if root.Parent() != nil {
+		t.Fatal("the root has a parent")
+	}
+	if root.Rule() != "e" || root.Text() != buffer {
+		t.Fatalf("got root %v %q", root.Rule(), root.Text())
+	}
+	checkParents(t, root)
+
+	var numbers []string
+	for number := range root.FindAll("number") {
+		begin, end := number.Span()
+		if number.Text() != buffer[begin:end] {
+			t.Errorf("number %q spans %d to %d", number.Text(), begin, end)
+		}
+		numbers = append(numbers, strings.TrimSpace(number.Text()))
+	}
+	if want := []string{"1", "23", "4"}; !slices.Equal(numbers, want) {
+		t.Fatalf("got numbers %q, want %q", numbers, want)
+	}
+	for number := range root.FindAll("number") {
+		if number.Text() != "1 " {
+			t.Fatalf("got first number %q", number.Text())
+		}
+		break
+	}
+
+	e1 := root.FirstChild("e1")
+	var rules []string
+	for child := range e1.Children() {
+		rules = append(rules, child.Rule())
+	}
+	if want := []string{"e2", "add", "e2"}; !slices.Equal(rules, want) {
+		t.Fatalf("got children %q, want %q", rules, want)
+	}
+	if add := e1.FirstChild("add"); add == nil || add.Text() != "+ " {
+		t.Fatalf("got add %v", add)
+	}
+	if e1.FirstChild("minus") != nil {
+		t.Fatal("found a minus")
+	}
+}
@@ -0,0 +1,14 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package captures
+
+// Set records the value of key and the span of the value in the input.
+func (c *Config[_]) Set(key, value string, begin, end int) {
+	if c.Values == nil {
+		c.Values, c.Spans = make(map[string]string), make(map[string][2]int)
+	}
+	c.Values[key] = value
+	c.Spans[key] = [2]int{begin, end}
+}
@@ -0,0 +1,31 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline captures.peg
+
+package captures
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestCaptures(t *testing.T) {
+	config := &Config[uint32]{Buffer: "name = \"peg\";\nverbose;\n  level=3 ;"}
+	if err := config.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := config.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	config.Execute()
+	values := map[string]string{"name": `"peg"`, "verbose": "true", "level": "3"}
+	if !maps.Equal(config.Values, values) {
+		t.Fatalf("got %v, want %v", config.Values, values)
+	}
+	spans := map[string][2]int{"name": {7, 12}, "verbose": {14, 21}, "level": {31, 32}}
+	if !maps.Equal(config.Spans, spans) {
+		t.Fatalf("got spans %v, want %v", config.Spans, spans)
+	}
+}
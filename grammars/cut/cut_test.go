@@ -0,0 +1,48 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline cut.peg
+
+package cut
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCut(t *testing.T) {
+	for _, test := range []struct {
+		buffer       string
+		line, column int
+		expected     string
+	}{
+		{buffer: "print 1, 2;\nprinter = 3;\n"},
+		{"print = 1;\n", 1, 7, "List"},
+		{"x = 1;\nprint 2, ;\n", 2, 10, "value"},
+	} {
+		p := &Cut[uint32]{Buffer: test.buffer}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		err := p.Parse()
+		if test.expected == "" {
+			if err != nil {
+				t.Errorf("%q: %v", test.buffer, err)
+			}
+			continue
+		}
+		var parseError *ParseError
+		if !errors.As(err, &parseError) {
+			t.Errorf("%q: expected a *ParseError, got %v", test.buffer, err)
+			continue
+		}
+		if parseError.Line != test.line || parseError.Column != test.column {
+			t.Errorf("%q: expected line %d col %d, got line %d col %d", test.buffer,
+				test.line, test.column, parseError.Line, parseError.Column)
+		}
+		if len(parseError.Expected) != 1 || parseError.Expected[0] != test.expected {
+			t.Errorf("%q: expected %v, got %v", test.buffer, test.expected, parseError.Expected)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package discard
+
+// more reports whether fewer than limit x's have been parsed.
+func (p *Discard[_]) more() bool {
+	p.count++
+	return p.count <= p.limit
+}
@@ -0,0 +1,30 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline -stream discard.peg
+
+package discard
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestFailureDiscarded checks the error of a parse whose farthest failure
+// is in input that has already been discarded.
+func TestFailureDiscarded(t *testing.T) {
+	p := &Discard[uint32]{limit: 10000}
+	if err := p.InitReader(strings.NewReader(strings.Repeat("x", 10000))); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Parse()
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if base := int(p.base); base == 0 || parseError.Offset != base {
+		t.Errorf("got the error at %d, expected it where the input kept starts, at %d", parseError.Offset, base)
+	}
+}
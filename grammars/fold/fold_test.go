@@ -0,0 +1,34 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline fold.peg
+
+package fold
+
+import (
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	for _, test := range []struct {
+		buffer string
+		ok     bool
+	}{
+		{"été ÉTÉ Été", true},
+		{"kelvin KELVIN Kelvin", true},
+		{"σίσυφος ΣΊΣΥΦΟΣ ΣΊΣΥΦΟς", true},
+		{"x0f XAb", true},
+		{"ete", false},
+		{"ÉTE", false},
+		{"xg", false},
+	} {
+		p := &Fold[uint32]{Buffer: test.buffer}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Parse(); (err == nil) != test.ok {
+			t.Errorf("%q: expected ok %v, got %v", test.buffer, test.ok, err)
+		}
+	}
+}
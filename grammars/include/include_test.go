@@ -0,0 +1,39 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline include.peg
+
+package include
+
+import "testing"
+
+func TestInclude(t *testing.T) {
+	p := &Include[uint32]{Buffer: "a = 1 # one\nbc = 23\n"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	var count func(node *node[uint32])
+	count = func(node *node[uint32]) {
+		for ; node != nil; node = node.next {
+			counts[rul3s[node.pegRule]]++
+			count(node.up)
+		}
+	}
+	count(p.AST())
+	for rule, expected := range map[string]int{
+		"Identifier":     2,
+		"number.Value":   2,
+		"number.Digits":  2,
+		"number.Comment": 1,
+	} {
+		if counts[rule] != expected {
+			t.Errorf("expected %d %v in the syntax tree, got %d", expected, rule, counts[rule])
+		}
+	}
+}
@@ -0,0 +1,143 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch incremental.peg
+
+package incremental
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func program(n int) string {
+	var b strings.Builder
+	for i := range n {
+		fmt.Fprintf(&b, "x%s = %d + (y - %d);\nprint x%s;\n", strings.Repeat("a", i%5), i, i, strings.Repeat("a", i%5))
+	}
+	return b.String()
+}
+
+// check parses the input of p from scratch and compares the result with
+// the one p got.
+func check(t *testing.T, p *Incremental[uint32], err error) {
+	t.Helper()
+	q := &Incremental[uint32]{Buffer: p.Buffer}
+	if err := q.Init(); err != nil {
+		t.Fatal(err)
+	}
+	expected := q.Parse()
+	if fmt.Sprint(err) != fmt.Sprint(expected) {
+		t.Fatalf("%q: got error %v, expected %v", p.Buffer, err, expected)
+	}
+	if err == nil && !slices.Equal(p.Tokens(), q.Tokens()) {
+		t.Fatalf("%q: the tokens differ from a new parse", p.Buffer)
+	}
+}
+
+func TestEdit(t *testing.T) {
+	testEdit(t)
+}
+
+func TestEditSparseMemo(t *testing.T) {
+	testEdit(t, SparseMemo[uint32]())
+}
+
+func testEdit(t *testing.T, options ...func(*Incremental[uint32]) error) {
+	p := &Incremental[uint32]{Buffer: program(100)}
+	if err := p.Init(options...); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.evaluated != 201 {
+		t.Fatalf("%d statements were evaluated", p.evaluated)
+	}
+	start := strings.Index(p.Buffer, "= 50 ") + 2
+	for _, edit := range []struct {
+		oldEnd int
+		text   string
+	}{
+		{start + 2, "5000"},
+		{start + 4, "7"},
+		{start, "(1 + 2) - "},
+	} {
+		p.evaluated = 0
+		if err := p.Edit(start, edit.oldEnd, edit.text); err != nil {
+			t.Fatal(err)
+		}
+		err := p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, p, err)
+		if p.evaluated > 1 {
+			t.Errorf("%d statements were evaluated again", p.evaluated)
+		}
+	}
+}
+
+func TestEditRandom(t *testing.T) {
+	random := rand.New(rand.NewPCG(1, 2))
+	const symbols = "ab01 \n=+-();print"
+	p := &Incremental[uint32]{Buffer: program(10)}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, p, p.Parse())
+	for range 1000 {
+		buffer := []rune(p.Buffer)
+		start := random.IntN(len(buffer) + 1)
+		oldEnd := min(start+random.IntN(4), len(buffer))
+		text := make([]byte, random.IntN(4))
+		for i := range text {
+			text[i] = symbols[random.IntN(len(symbols))]
+		}
+		if err := p.Edit(start, oldEnd, string(text)); err != nil {
+			t.Fatal(err)
+		}
+		check(t, p, p.Parse())
+		// undo the edit
+		if err := p.Edit(start, start+len(text), string(buffer[start:oldEnd])); err != nil {
+			t.Fatal(err)
+		}
+		err := p.Parse()
+		if err != nil {
+			t.Fatal(err)
+		}
+		check(t, p, err)
+	}
+}
+
+func TestEditInvalid(t *testing.T) {
+	p := &Incremental[uint32]{Buffer: program(2)}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	check(t, p, p.Parse())
+	buffer, length := p.Buffer, len([]rune(p.Buffer))
+	for _, edit := range []struct {
+		start, oldEnd int
+	}{
+		{-1, 0},
+		{3, 2},
+		{0, length + 1},
+		{length + 1, length + 1},
+	} {
+		if err := p.Edit(edit.start, edit.oldEnd, "x"); err == nil {
+			t.Errorf("%d to %d: expected an error", edit.start, edit.oldEnd)
+		}
+		if p.Buffer != buffer {
+			t.Fatalf("%d to %d: the input was edited", edit.start, edit.oldEnd)
+		}
+	}
+	if err := p.Edit(length, length, "print x;\n"); err != nil {
+		t.Fatal(err)
+	}
+	check(t, p, p.Parse())
+}
@@ -0,0 +1,12 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package incremental
+
+// evaluate counts the statements parsed, which Edit shouldn't parse again
+// when they are reused.
+func (p *Incremental[_]) evaluate() bool {
+	p.evaluated++
+	return true
+}
@@ -0,0 +1,51 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline -output incremental.peg.go ../incremental.peg
+
+// Package incremental is the incremental grammar generated with -inline, to
+// check Edit still reuses the statements marked @memo.
+package incremental
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestEditInline(t *testing.T) {
+	var b strings.Builder
+	for i := range 100 {
+		fmt.Fprintf(&b, "x = %d + (y - %d);\nprint x;\n", i, i)
+	}
+	p := &Incremental[uint32]{Buffer: b.String()}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	start := strings.Index(p.Buffer, "= 50 ") + 2
+	p.evaluated = 0
+	if err := p.Edit(start, start+2, "5000"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.evaluated > 1 {
+		t.Errorf("%d statements were evaluated again", p.evaluated)
+	}
+	q := &Incremental[uint32]{Buffer: p.Buffer}
+	if err := q.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(p.Tokens(), q.Tokens()) {
+		t.Error("the tokens differ from a new parse")
+	}
+}
@@ -32,6 +32,18 @@ func TestBasic(t *testing.T) {
 	}
 }
 
+func TestUnicodeIdentifiers(t *testing.T) {
+	java := &Java[uint32]{Buffer: "class Ĉapo { double π = 3.14; int $count_1, ｘ\u0301; }"}
+	err := java.Init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := java.Parse(); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestJavaFiles(t *testing.T) {
 	err := filepath.Walk(".", func(path string, _ fs.FileInfo, err error) error {
 		if err != nil {
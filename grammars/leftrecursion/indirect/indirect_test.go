@@ -0,0 +1,25 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline indirect.peg
+
+// Package indirect is a grammar whose only memoized rules are left recursive.
+package indirect
+
+import (
+	"testing"
+)
+
+func TestIndirect(t *testing.T) {
+	p := &Indirect[uint32]{Buffer: "a+a+a"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if end := p.AST().end; end != 5 {
+		t.Fatalf("got a match to %d, want 5", end)
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline leftrecursion.peg
+
+package leftrecursion
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sexp renders the rules of interest in the AST as an s-expression.
+func sexp[U Uint](n *node[U], buffer string) string {
+	var b strings.Builder
+	var walk func(n *node[U])
+	walk = func(n *node[U]) {
+		switch n.pegRule {
+		case ruleExpression, ruleTerm, ruleCall:
+			b.WriteString("(")
+			first := true
+			for child := n.up; child != nil; child = child.next {
+				if !first {
+					b.WriteString(" ")
+				}
+				first = false
+				walk(child)
+			}
+			b.WriteString(")")
+		case ruleFactor, rulePostfix:
+			walk(n.up)
+		default:
+			b.WriteString(strings.TrimSpace(buffer[n.begin:n.end]))
+		}
+	}
+	walk(n)
+	return b.String()
+}
+
+func TestLeftRecursion(t *testing.T) {
+	tt := []struct {
+		input, expected string
+	}{
+		{"1", "((1))"},
+		{"1 - 2 - 3", "((((1)) - (2)) - (3))"},
+		{"1 + 2 * 3 * 4", "(((1)) + (((2) * 3) * 4))"},
+		{"a.b.c()", "(((((a . b) . c) ( ))))"},
+	}
+
+	for _, tc := range tt {
+		p := &LeftRecursion[uint32]{Buffer: tc.input}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Parse(); err != nil {
+			t.Fatalf("%q: %v", tc.input, err)
+		}
+		start := p.AST()
+		if actual := sexp(start.up, tc.input); actual != tc.expected {
+			t.Errorf("%q: expected %v, got %v", tc.input, tc.expected, actual)
+		}
+	}
+}
+
+func TestLeftRecursionNoMemoize(t *testing.T) {
+	p := &LeftRecursion[uint32]{Buffer: "x.y() * 2 - 3"}
+	if err := p.Init(DisableMemoize[uint32]()); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.AST().end != 13 {
+		t.Fatal("expected the whole input to be consumed")
+	}
+}
+
+// TestLongLeftRecursion checks that growing a seed over many terms takes
+// memory in proportion to them, as the memoized seed is overwritten each
+// time it grows.
+func TestLongLeftRecursion(t *testing.T) {
+	allocated := func(terms int) uint64 {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		p := &LeftRecursion[uint32]{Buffer: "1" + strings.Repeat(" + 1", terms)}
+		if err := p.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := p.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		runtime.ReadMemStats(&after)
+		return after.TotalAlloc - before.TotalAlloc
+	}
+	if short, long := allocated(1000), allocated(4000); long > 8*short {
+		t.Errorf("parsing 4000 terms allocated %d bytes, more than 8 times the %d of 1000 terms", long, short)
+	}
+}
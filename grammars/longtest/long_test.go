@@ -7,6 +7,7 @@
 package longtest
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -31,3 +32,16 @@ func TestLong(t *testing.T) {
 		long.Buffer = "\"" + expression + "\""
 	}
 }
+
+func BenchmarkLong(b *testing.B) {
+	long := &Long[uint32]{Buffer: "\"" + strings.Repeat("X", 10000) + "\""}
+	if err := long.Init(); err != nil {
+		b.Fatal(err)
+	}
+	for b.Loop() {
+		if err := long.Parse(); err != nil {
+			b.Fatal(err)
+		}
+		long.Reset()
+	}
+}
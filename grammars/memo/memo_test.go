@@ -0,0 +1,37 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch memo.peg
+
+package memo
+
+import (
+	"testing"
+)
+
+func TestMemo(t *testing.T) {
+	type counts struct{ memoHits, reparses int }
+	for _, test := range []struct {
+		input    string
+		expected map[string]counts
+	}{
+		/* B is reached at varying distances from X, so it is memoized */
+		{"abcd", map[string]counts{"S": {}, "X": {}, "B": {memoHits: 1}}},
+		/* Spaces is @nomemo, so it is parsed again */
+		{"  y", map[string]counts{"S": {}, "X": {}, "B": {}, "Spaces": {reparses: 1}}},
+	} {
+		m := &Memo[uint32]{Buffer: test.input}
+		if err := m.Init(EnableProfiling[uint32]()); err != nil {
+			t.Fatal(err)
+		}
+		if err := m.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		for _, profile := range m.Profile() {
+			if got := (counts{profile.MemoHits, profile.Reparses}); got != test.expected[profile.Rule] {
+				t.Errorf("%q: got %+v for %v, want %+v", test.input, got, profile.Rule, test.expected[profile.Rule])
+			}
+		}
+	}
+}
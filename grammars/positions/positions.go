@@ -0,0 +1,22 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package positions
+
+import (
+	"fmt"
+)
+
+// declare records where name is declared, reporting a name declared twice.
+func (d *Declarations[_]) declare(name string, pos Position) {
+	if d.declared == nil {
+		d.declared = make(map[string]Position)
+	}
+	if first, ok := d.declared[name]; ok {
+		d.Errors = append(d.Errors, fmt.Sprintf("%d:%d: %s redeclared, first declared at %d:%d",
+			pos.Line, pos.Column, name, first.Line, first.Column))
+		return
+	}
+	d.declared[name] = pos
+}
@@ -0,0 +1,58 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline positions.peg
+
+package positions
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestPositionsInActions(t *testing.T) {
+	d := &Declarations[uint32]{Buffer: "let ß = \"é\"; let x = 1;\nlet y = 2;\n  let ß = 3;\n\tlet x=4;"}
+	if err := d.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	d.Execute()
+	errors := []string{
+		"3:7: ß redeclared, first declared at 1:5",
+		"4:6: x redeclared, first declared at 1:18",
+	}
+	if !slices.Equal(d.Errors, errors) {
+		t.Fatalf("got errors %q, want %q", d.Errors, errors)
+	}
+}
+
+func TestPosition(t *testing.T) {
+	var b strings.Builder
+	for i := range 40 {
+		fmt.Fprintf(&b, "let v%d = \"%s\";\n", i, strings.Repeat("é€x", i*7))
+	}
+	buffer := b.String()
+	d := &Declarations[uint32]{Buffer: buffer}
+	if err := d.Init(); err != nil {
+		t.Fatal(err)
+	}
+	want := Position{Line: 1, Column: 1}
+	for i, c := range []rune(buffer) {
+		if got := d.Position(i); got != want {
+			t.Fatalf("got %+v at %d, want %+v", got, i, want)
+		}
+		want.Offset, want.Rune, want.Column = want.Offset+utf8.RuneLen(c), want.Rune+1, want.Column+1
+		if c == '\n' {
+			want.Line, want.Column = want.Line+1, 1
+		}
+	}
+	if got := d.Position(utf8.RuneCountInString(buffer)); got != want {
+		t.Fatalf("got %+v at the end, want %+v", got, want)
+	}
+}
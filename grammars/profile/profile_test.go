@@ -0,0 +1,56 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline profile.peg
+
+package profile
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	for _, test := range []struct {
+		memoize  bool
+		profiles []RuleProfile
+	}{
+		{true, []RuleProfile{
+			{Rule: "Greeting", Calls: 1, Matches: 1},
+			{Rule: "Name", Calls: 2, Matches: 2, MemoHits: 1},
+			{Rule: "sp", Calls: 4, Matches: 4, MemoHits: 2},
+		}},
+		{false, []RuleProfile{
+			{Rule: "Greeting", Calls: 1, Matches: 1},
+			{Rule: "Name", Calls: 2, Matches: 2, Reparses: 1},
+			{Rule: "sp", Calls: 6, Matches: 6, Reparses: 4},
+		}},
+	} {
+		g := &Greeting[uint32]{Buffer: "hello\nyou ?"}
+		options := []func(*Greeting[uint32]) error{EnableProfiling[uint32]()}
+		if !test.memoize {
+			options = append(options, DisableMemoize[uint32]())
+		}
+		if err := g.Init(options...); err != nil {
+			t.Fatal(err)
+		}
+		if err := g.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		profiles := g.Profile()
+		for i := 1; i < len(profiles); i++ {
+			if profiles[i].Time > profiles[i-1].Time {
+				t.Errorf("%v is sorted after %v", profiles[i].Rule, profiles[i-1].Rule)
+			}
+		}
+		for i := range profiles {
+			profiles[i].Time = 0
+		}
+		slices.SortFunc(profiles, func(a, b RuleProfile) int { return strings.Compare(a.Rule, b.Rule) })
+		if !slices.Equal(profiles, test.profiles) {
+			t.Errorf("got profiles %+v, want %+v", profiles, test.profiles)
+		}
+	}
+}
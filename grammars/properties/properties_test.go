@@ -0,0 +1,36 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline properties.peg
+
+package properties
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProperties(t *testing.T) {
+	p := &Properties[uint32]{Buffer: "αβγ x1 _y ٣٤ 42 +\n中文 λx"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	for rule, expected := range map[string][]string{
+		"Greek":      {"αβγ"},
+		"Identifier": {"x1", "_y", "中文", "λx"},
+		"Number":     {"٣٤", "42"},
+		"Symbol":     {"+"},
+	} {
+		var texts []string
+		for n := range p.AST().FindAll(rule) {
+			texts = append(texts, n.Text())
+		}
+		if !slices.Equal(texts, expected) {
+			t.Errorf("got %v matched by %v, want %v", texts, rule, expected)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -output recovery.peg.go ../recovery.peg
+
+// Package recovery is the recovery grammar generated without -inline, which
+// the tests of grammars/recovery check reports the same parse errors.
+package recovery
@@ -0,0 +1,145 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline recovery.peg
+
+package recovery
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"testing"
+
+	noinline "github.com/pointlander/peg/grammars/recovery/noinline"
+)
+
+// reported is a parse error reported by either parser.
+type reported struct {
+	line, column int
+	expected     []string
+}
+
+// result is what a parser reports for an input.
+type result struct {
+	err         error
+	diagnostics []reported
+	rules       []string
+}
+
+// parsers are the recovery grammar generated with and without -inline,
+// which are to report the same errors.
+var parsers = []struct {
+	name  string
+	parse func(buffer string) (result, error)
+}{
+	{"inline", func(buffer string) (result, error) {
+		p := &Recovery[uint32]{Buffer: buffer}
+		if err := p.Init(); err != nil {
+			return result{}, err
+		}
+		r := result{err: p.Parse()}
+		if r.err != nil && !errors.As(r.err, new(*ParseError)) {
+			return result{}, fmt.Errorf("expected a *ParseError, got %T", r.err)
+		}
+		for _, d := range p.Diagnostics() {
+			r.diagnostics = append(r.diagnostics, reported{d.Line, d.Column, d.Expected})
+		}
+		if root := p.AST(); root != nil {
+			r.rules = rules(root.Children())
+		}
+		return r, nil
+	}},
+	{"noinline", func(buffer string) (result, error) {
+		p := &noinline.Recovery[uint32]{Buffer: buffer}
+		if err := p.Init(); err != nil {
+			return result{}, err
+		}
+		r := result{err: p.Parse()}
+		if r.err != nil && !errors.As(r.err, new(*noinline.ParseError)) {
+			return result{}, fmt.Errorf("expected a *ParseError, got %T", r.err)
+		}
+		for _, d := range p.Diagnostics() {
+			r.diagnostics = append(r.diagnostics, reported{d.Line, d.Column, d.Expected})
+		}
+		if root := p.AST(); root != nil {
+			r.rules = rules(root.Children())
+		}
+		return r, nil
+	}},
+}
+
+// rules returns the rules of nodes.
+func rules[N interface{ Rule() string }](nodes iter.Seq[N]) []string {
+	var rules []string
+	for n := range nodes {
+		rules = append(rules, n.Rule())
+	}
+	return rules
+}
+
+func TestRecovery(t *testing.T) {
+	for _, test := range []struct {
+		name        string
+		buffer      string
+		diagnostics []reported
+		statements  int
+	}{
+		{
+			name:   "recovered",
+			buffer: "a = 1;\nb = ;\nc = 3;\nd 4;\ne = 5;\n",
+			diagnostics: []reported{
+				{2, 5, []string{"value"}},
+				{4, 3, []string{"'='"}},
+			},
+			statements: 5,
+		},
+		{
+			name:   "unrecovered",
+			buffer: "a = 1;\nb = 2",
+			/* the parse error is the only diagnostic */
+			diagnostics: []reported{
+				{2, 6, []string{"any character", "';'"}},
+			},
+		},
+	} {
+		for _, parser := range parsers {
+			t.Run(test.name+"/"+parser.name, func(t *testing.T) {
+				r, err := parser.parse(test.buffer)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if r.err == nil {
+					t.Fatal("expected syntax errors")
+				}
+				if len(r.diagnostics) != len(test.diagnostics) {
+					t.Fatalf("expected %d diagnostics, got %d: %v", len(test.diagnostics), len(r.diagnostics), r.err)
+				}
+				for i, expected := range test.diagnostics {
+					diagnostic := r.diagnostics[i]
+					if diagnostic.line != expected.line || diagnostic.column != expected.column {
+						t.Errorf("#%d: expected line %d col %d, got line %d col %d", i,
+							expected.line, expected.column, diagnostic.line, diagnostic.column)
+					}
+					if !slices.Equal(diagnostic.expected, expected.expected) {
+						t.Errorf("#%d: expected %v, got %v", i, expected.expected, diagnostic.expected)
+					}
+				}
+				if test.statements == 0 {
+					return
+				}
+				statements := 0
+				for _, rule := range r.rules {
+					if rule == "Statement" {
+						statements++
+					}
+				}
+				if statements != test.statements {
+					t.Fatalf("expected %d statements in the syntax tree, got %d", test.statements, statements)
+				}
+			})
+		}
+	}
+}
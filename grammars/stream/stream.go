@@ -0,0 +1,22 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stream
+
+import (
+	"strconv"
+)
+
+// add adds the number of a line to the sum, counting the lines it isn't
+// found at the start of.
+func (p *Stream[_]) add(text string, pos Position) {
+	n, err := strconv.Atoi(text)
+	if err != nil {
+		panic(err)
+	}
+	p.sum += n
+	if pos.Line != p.lines+1 || pos.Column != 1 {
+		p.misplaced++
+	}
+}
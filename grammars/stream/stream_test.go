@@ -0,0 +1,128 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline -stream stream.peg
+
+package stream
+
+import (
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// lines reads n lines holding the numbers 1 to n, keeping track of the
+// largest buffer the parser has held on to.
+type lines struct {
+	n, i    int
+	pending []byte
+	parser  *Stream[uint32]
+	largest int
+}
+
+func (l *lines) Read(b []byte) (int, error) {
+	if len(l.parser.buffer) > l.largest {
+		l.largest = len(l.parser.buffer)
+	}
+	if len(l.pending) == 0 {
+		if l.i == l.n {
+			return 0, io.EOF
+		}
+		l.i++
+		l.pending = []byte(strconv.Itoa(l.i) + "\n")
+	}
+	n := copy(b, l.pending)
+	l.pending = l.pending[n:]
+	return n, nil
+}
+
+func TestStream(t *testing.T) {
+	const n = 100000
+	p := &Stream[uint32]{}
+	input := &lines{n: n, parser: p}
+	if err := p.InitReader(input); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.lines != n || p.sum != n*(n+1)/2 {
+		t.Errorf("got %d lines summing to %d", p.lines, p.sum)
+	}
+	if p.misplaced != 0 {
+		t.Errorf("%d numbers were not at the start of their line", p.misplaced)
+	}
+	if input.largest > 1<<16 {
+		t.Errorf("the buffer grew to %d runes", input.largest)
+	}
+	if len(p.Tokens()) > 1<<16 {
+		t.Errorf("%d tokens were kept", len(p.Tokens()))
+	}
+}
+
+func TestStreamError(t *testing.T) {
+	input := strings.Repeat("1\n", 5000) + "12 x\n"
+	p := &Stream[uint32]{}
+	if err := p.InitReader(strings.NewReader(input)); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Parse()
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected a *ParseError, got %v", err)
+	}
+	if parseError.Line != 5001 || parseError.Column != 4 || parseError.Offset != 10003 {
+		t.Errorf("got %d:%d at %d, expected 5001:4 at 10003", parseError.Line, parseError.Column, parseError.Offset)
+	}
+	// only the actions of the input discarded before the error have run
+	if p.lines == 0 || p.lines > 5000 {
+		t.Errorf("got %d lines before the error", p.lines)
+	}
+}
+
+func TestStreamProperties(t *testing.T) {
+	input := strings.Repeat("1 #été à la mer\n2\n", 3000)
+	p := &Stream[uint32]{}
+	if err := p.InitReader(iotest.OneByteReader(strings.NewReader(input))); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.lines != 6000 || p.sum != 9000 || p.labels != 3000 {
+		t.Errorf("got %d lines summing to %d with %d labels", p.lines, p.sum, p.labels)
+	}
+}
+
+func TestStreamBuffer(t *testing.T) {
+	p := &Stream[uint32]{Buffer: "1\n2\n3\n"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if p.lines != 3 || p.sum != 6 {
+		t.Errorf("got %d lines summing to %d", p.lines, p.sum)
+	}
+}
+
+type failing struct{}
+
+func (failing) Read([]byte) (int, error) {
+	return 0, errors.New("broken")
+}
+
+func TestStreamReadError(t *testing.T) {
+	p := &Stream[uint32]{}
+	if err := p.InitReader(failing{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err == nil || err.Error() != "broken" {
+		t.Errorf("expected the read error, got %v", err)
+	}
+}
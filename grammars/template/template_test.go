@@ -0,0 +1,77 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline template.peg
+
+package template
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	p := &Template[uint32]{Buffer: "a: 1 | 2,\nb: 3\n"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	counts := make(map[string]int)
+	var count func(node *node[uint32])
+	count = func(node *node[uint32]) {
+		for ; node != nil; node = node.next {
+			counts[rul3s[node.pegRule]]++
+			count(node.up)
+		}
+	}
+	count(p.AST())
+	for rule, expected := range map[string]int{
+		"List(Pair, Token(','))":         1,
+		"List(Token(Value), Token('|'))": 2,
+		"Token(Value)":                   3,
+		"Token(':')":                     2,
+	} {
+		if counts[rule] != expected {
+			t.Errorf("expected %d %v in the syntax tree, got %d", expected, rule, counts[rule])
+		}
+	}
+}
+
+// TestNotATemplate checks a rule that isn't a template followed by an
+// expression in parentheses is a sequence, the suffix applying to the
+// expression.
+func TestNotATemplate(t *testing.T) {
+	p := &Template[uint32]{Buffer: "ab1: 1,\nc: 2\n"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for key := range p.AST().FindAll("Key") {
+		keys = append(keys, key.Text())
+	}
+	if want := []string{"ab1", "c"}; !slices.Equal(keys, want) {
+		t.Fatalf("got keys %q, want %q", keys, want)
+	}
+}
+
+func TestTemplateError(t *testing.T) {
+	p := &Template[uint32]{Buffer: "a: 1 |"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	err := p.Parse()
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	if !strings.Contains(err.Error(), "expected Token(Value) at line 1 col 7") {
+		t.Fatalf("expected the error to name the template instance, got %v", err)
+	}
+}
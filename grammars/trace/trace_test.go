@@ -0,0 +1,59 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline -trace trace.peg
+
+package trace
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTrace(t *testing.T) {
+	var b strings.Builder
+	g := &Greeting[uint32]{Buffer: "hello\nyou ?"}
+	if err := g.Init(Trace[uint32](&b)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	trace := `enter Greeting at 1:1
+  enter Name at 1:1
+    enter sp at 1:6
+    match sp at 1:6 to 2:1
+    enter sp at 2:4
+    match sp at 2:4 to 2:5
+    backtrack to 2:4 from 2:5
+  match Name at 1:1 to 2:4
+  enter sp at 2:4
+    memoized match to 2:5
+  match sp at 2:4 to 2:5
+  backtrack to 1:1 from 2:5
+  enter Name at 1:1
+    memoized match to 2:4
+  match Name at 1:1 to 2:4
+  enter sp at 2:4
+    memoized match to 2:5
+  match sp at 2:4 to 2:5
+match Greeting at 1:1 to 2:6
+`
+	if b.String() != trace {
+		t.Fatalf("got trace\n%v\nwant\n%v", b.String(), trace)
+	}
+}
+
+func TestTraceOff(t *testing.T) {
+	g := &Greeting[uint32]{Buffer: "hello!"}
+	if err := g.Init(Trace[uint32](nil)); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if g.trace != nil {
+		t.Fatal("the parse is traced")
+	}
+}
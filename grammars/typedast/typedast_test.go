@@ -0,0 +1,98 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline -typed-ast typedast.peg
+
+package typedast
+
+import (
+	"strconv"
+	"testing"
+)
+
+func (s *SumNode[_]) eval() int {
+	a := s.Product[0].eval()
+	for i, operator := range s.SumOperator {
+		b := s.Product[i+1].eval()
+		if operator.Text == "+" {
+			a += b
+		} else {
+			a -= b
+		}
+	}
+	return a
+}
+
+func (p *ProductNode[_]) eval() int {
+	a := p.Value[0].eval()
+	for i, operator := range p.ProductOperator {
+		b := p.Value[i+1].eval()
+		if operator.Text == "*" {
+			a *= b
+		} else {
+			a /= b
+		}
+	}
+	return a
+}
+
+func (v *ValueNode[_]) eval() int {
+	if v.Number != nil {
+		n, err := strconv.Atoi(v.Number.Text)
+		if err != nil {
+			panic(err)
+		}
+		return n
+	}
+	return v.Sum.eval()
+}
+
+func TestTypedAST(t *testing.T) {
+	for _, test := range []struct {
+		expression string
+		value      int
+	}{
+		{"1", 1},
+		{"1 + 2 * 3", 7},
+		{"(1 + 2) * 3 - 4 / 2", 7},
+		{" 10 - (2 - -3) * (4)", -10},
+	} {
+		calc := &Calculator[uint32]{Buffer: test.expression}
+		if err := calc.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := calc.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		expression := calc.TypedAST()
+		if expression == nil || expression.Sum == nil {
+			t.Fatalf("%q: no typed syntax tree", test.expression)
+		}
+		if value := expression.Sum.eval(); value != test.value {
+			t.Errorf("%q: got %d, expected %d", test.expression, value, test.value)
+		}
+	}
+}
+
+func TestTypedASTSpans(t *testing.T) {
+	calc := &Calculator[uint32]{Buffer: "(12) + 3"}
+	if err := calc.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := calc.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	sum := calc.TypedAST().Sum
+	value := sum.Product[0].Value[0]
+	if value.begin != 0 || value.end != 5 || value.Sum.Product[0].Value[0].Number.Text != "12" {
+		t.Errorf("unexpected value %v", value)
+	}
+	if sum.Product[1].Value[0].Number.Sp != nil {
+		t.Error("expected no match of sp at the end of the input")
+	}
+	calc.Reset()
+	if calc.Parse(int(ruleSum)); calc.TypedAST() != nil {
+		t.Error("expected no typed syntax tree for a parse starting from Sum")
+	}
+}
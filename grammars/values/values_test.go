@@ -0,0 +1,36 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -inline values.peg
+
+package values
+
+import (
+	"testing"
+)
+
+func TestValues(t *testing.T) {
+	for expression, want := range map[string]int{
+		"7":                      7,
+		"1 - 2 - 3":              -4,
+		"2 * 3 + 4 * 5":          26,
+		"100 / 7 / 2":            7,
+		"-(2 + 3) * -4":          20,
+		"((1)) + (2 * (3 - 1))":  5,
+		" 10 - (2 - -3) * (4)":   -10,
+		"1 + 2 * 3 - 4 / 2 + 10": 15,
+	} {
+		calc := &Calculator[uint32]{Buffer: expression}
+		if err := calc.Init(); err != nil {
+			t.Fatal(err)
+		}
+		if err := calc.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		calc.Execute()
+		if calc.Result != want {
+			t.Errorf("%q = %d, want %d", expression, calc.Result, want)
+		}
+	}
+}
@@ -0,0 +1,31 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch -typed-ast -output visitor.peg.go ../visitor.peg
+
+// Package visitor is the visitor grammar generated with -typed-ast, to
+// check the structs of value and Value get different names.
+package visitor
+
+import (
+	"testing"
+)
+
+func TestCollidingNodes(t *testing.T) {
+	p := &Nested[uint32]{Buffer: "1,[22,3],4"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	values := p.TypedAST().List.value
+	if len(values) != 3 || values[1].List == nil || len(values[1].List.value) != 2 {
+		t.Fatalf("unexpected values %v", values)
+	}
+	var value *ValueNode[uint32] = values[1].List.value[0].Value
+	if value.begin != 3 || value.end != 5 {
+		t.Errorf("got the value of 22 from %d to %d, want 3 to 5", value.begin, value.end)
+	}
+}
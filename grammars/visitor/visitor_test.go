@@ -0,0 +1,44 @@
+// Copyright 2010 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:generate go tool peg -strict -switch visitor.peg
+
+package visitor
+
+import (
+	"slices"
+	"testing"
+)
+
+// recorder records the calls of the rules 'value' and 'Value', whose
+// methods can't both be named EnterValue and ExitValue.
+type recorder struct {
+	BaseVisitor[uint32]
+	calls []string
+}
+
+func (r *recorder) Entervalue(*node[uint32]) { r.calls = append(r.calls, "enter value") }
+func (r *recorder) Exitvalue(*node[uint32])  { r.calls = append(r.calls, "exit value") }
+func (r *recorder) EnterValue(n *node[uint32]) {
+	r.calls = append(r.calls, "enter Value "+n.Text())
+}
+
+func TestCollidingMethods(t *testing.T) {
+	p := &Nested[uint32]{Buffer: "1,[2]"}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	r := &recorder{}
+	Walk(p.AST(), r)
+	want := []string{
+		"enter value", "enter Value 1", "exit value",
+		"enter value", "enter value", "enter Value 2", "exit value", "exit value",
+	}
+	if !slices.Equal(r.calls, want) {
+		t.Fatalf("got calls %q, want %q", r.calls, want)
+	}
+}
@@ -10,6 +10,9 @@ import (
 	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/pointlander/peg/tree"
 )
@@ -26,6 +29,10 @@ var (
 	syntax      = flag.Bool("syntax", false, "print out the syntax tree")
 	noast       = flag.Bool("noast", false, "disable AST")
 	strict      = flag.Bool("strict", false, "treat compiler warnings as errors")
+	bytesFlag   = flag.Bool("bytes", false, "generate a parser over []byte instead of []rune")
+	stream      = flag.Bool("stream", false, "generate a parser that can read its input from an io.Reader")
+	typedAst    = flag.Bool("typed-ast", false, "generate a Go struct for each rule and a constructor building them from the syntax tree")
+	trace       = flag.Bool("trace", false, "generate a parser tracing its rules, memoized results and backtracking")
 	outputFile  = flag.String("output", "", "output to `FILE` (\"-\" for stdout)")
 	showVersion = flag.Bool("version", false, "print the version and exit")
 )
@@ -49,6 +56,10 @@ func main() {
 			}
 
 			p.Strict = *strict
+			p.Bytes = *bytesFlag
+			p.Stream = *stream
+			p.TypedAst = *typedAst
+			p.Trace = *trace
 			if err := p.Compile(*outputFile, os.Args, out); err != nil {
 				return err
 			}
@@ -122,5 +133,44 @@ func parse(compile func(*Peg[uint32], io.Writer) error) error {
 
 	p.Execute()
 
+	path := "."
+	if flag.NArg() > 0 && flag.Arg(0) != "-" {
+		path = flag.Arg(0)
+	}
+	if err = include(p.Tree, path, nil); err != nil {
+		return err
+	}
+
 	return compile(p, out)
 }
+
+// include parses the grammar files included by the grammar at path, along
+// with the files they include in turn, and merges their rules into t.
+func include(t *tree.Tree, path string, including []string) error {
+	including = append(including, filepath.Clean(path))
+	for _, i := range t.Includes {
+		i.Path = filepath.Join(filepath.Dir(path), i.Path)
+		if slices.Contains(including, i.Path) {
+			return fmt.Errorf("include cycle: %v", strings.Join(append(including, i.Path), " -> "))
+		}
+		buffer, err := os.ReadFile(i.Path)
+		if err != nil {
+			return err
+		}
+
+		p := &Peg[uint32]{Tree: tree.New(*inline, *switchFlag, *noast), Buffer: string(buffer)}
+		_ = p.Init(Pretty[uint32](true), Size[uint32](1<<15))
+		if err = p.Parse(); err != nil {
+			return fmt.Errorf("%v: %w", i.Path, err)
+		}
+		p.Execute()
+
+		if err = include(p.Tree, i.Path, including); err != nil {
+			return err
+		}
+		if err = t.Merge(p.Tree, i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
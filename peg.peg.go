@@ -14,12 +14,19 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/pointlander/peg/tree"
 	"io"
+	"iter"
 	"os"
 	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 const endSymbol rune = 1114112
@@ -34,13 +41,20 @@ const (
 	ruleSingleImport
 	ruleMultiImport
 	ruleImportName
+	ruleInclude
 	ruleDefinition
+	ruleParameter
 	ruleExpression
 	ruleSequence
 	rulePrefix
 	ruleSuffix
 	rulePrimary
+	ruleArgument
 	ruleIdentifier
+	ruleTemplate
+	ruleAnnotation
+	ruleReference
+	ruleCall
 	ruleIdentStart
 	ruleIdentCont
 	ruleLiteral
@@ -49,6 +63,7 @@ const (
 	ruleDoubleRanges
 	ruleRange
 	ruleDoubleRange
+	ruleProperty
 	ruleChar
 	ruleDoubleChar
 	ruleEscape
@@ -59,8 +74,13 @@ const (
 	ruleQuestion
 	ruleStar
 	rulePlus
+	ruleCaret
+	ruleTilde
+	ruleResultType
 	ruleOpen
 	ruleClose
+	ruleComma
+	ruleColon
 	ruleDot
 	ruleSpaceComment
 	ruleSpacing
@@ -129,6 +149,22 @@ const (
 	ruleAction49
 	ruleAction50
 	ruleAction51
+	ruleAction52
+	ruleAction53
+	ruleAction54
+	ruleAction55
+	ruleAction56
+	ruleAction57
+	ruleAction58
+	ruleAction59
+	ruleAction60
+	ruleAction61
+	ruleAction62
+	ruleAction63
+	ruleAction64
+	ruleAction65
+	ruleAction66
+	ruleAction67
 )
 
 var rul3s = [...]string{
@@ -138,13 +174,20 @@ var rul3s = [...]string{
 	"SingleImport",
 	"MultiImport",
 	"ImportName",
+	"Include",
 	"Definition",
+	"Parameter",
 	"Expression",
 	"Sequence",
 	"Prefix",
 	"Suffix",
 	"Primary",
+	"Argument",
 	"Identifier",
+	"Template",
+	"Annotation",
+	"Reference",
+	"Call",
 	"IdentStart",
 	"IdentCont",
 	"Literal",
@@ -153,6 +196,7 @@ var rul3s = [...]string{
 	"DoubleRanges",
 	"Range",
 	"DoubleRange",
+	"Property",
 	"Char",
 	"DoubleChar",
 	"Escape",
@@ -163,8 +207,13 @@ var rul3s = [...]string{
 	"Question",
 	"Star",
 	"Plus",
+	"Caret",
+	"Tilde",
+	"ResultType",
 	"Open",
 	"Close",
+	"Comma",
+	"Colon",
 	"Dot",
 	"SpaceComment",
 	"Spacing",
@@ -233,6 +282,22 @@ var rul3s = [...]string{
 	"Action49",
 	"Action50",
 	"Action51",
+	"Action52",
+	"Action53",
+	"Action54",
+	"Action55",
+	"Action56",
+	"Action57",
+	"Action58",
+	"Action59",
+	"Action60",
+	"Action61",
+	"Action62",
+	"Action63",
+	"Action64",
+	"Action65",
+	"Action66",
+	"Action67",
 }
 
 type Uint interface {
@@ -250,9 +315,118 @@ func (t *token[_]) String() string {
 	return fmt.Sprintf("\x1B[34m%v\x1B[m %v %v", rul3s[t.pegRule], t.begin, t.end)
 }
 
+// node is a node of a syntax tree, the token of a match with up pointing to
+// its first child and next to its next sibling. The nodes of a tree are kept
+// in a single slice, one for each token, and are also linked to their
+// parent, their first child and their next sibling by index plus one, zero
+// being none.
 type node[U Uint] struct {
 	token[U]
-	up, next *node[U]
+	up, next                        *node[U]
+	tree                            *syntaxTree[U]
+	parentIndex, upIndex, nextIndex U
+}
+
+// syntaxTree holds the nodes of a syntax tree.
+type syntaxTree[U Uint] struct {
+	nodes  []node[U]
+	source *nodeSource[U]
+}
+
+// node returns the node linked to by i.
+func (t *syntaxTree[U]) node(i U) *node[U] {
+	if i == 0 {
+		return nil
+	}
+	return &t.nodes[i-1]
+}
+
+// nodeSource is the input the nodes of a syntax tree were parsed from.
+type nodeSource[U Uint] struct {
+	buffer []rune
+}
+
+// Node is a node of the syntax tree, the match of a rule. It can be used
+// outside of the package through its methods.
+type Node[U Uint] = node[U]
+
+// Rule returns the name of the rule n matched.
+func (n *node[_]) Rule() string {
+	return rul3s[n.pegRule]
+}
+
+// Span returns the positions of the start and the end of the match, counted
+// in runes.
+func (n *node[_]) Span() (begin, end int) {
+	return int(n.begin), int(n.end)
+}
+
+// Text returns the text n matched. It is empty for the nodes of a tree
+// built by tokens.AST, which doesn't know the input.
+func (n *node[_]) Text() string {
+	source := n.tree.source
+	if source == nil {
+		return ""
+	}
+	return string(source.buffer[n.begin:n.end])
+}
+
+// Parent returns the node n is a child of, or nil for the root.
+func (n *node[U]) Parent() *node[U] {
+	return n.tree.node(n.parentIndex)
+}
+
+// Up returns the first child of n, or nil if it has none.
+func (n *node[U]) Up() *node[U] {
+	return n.up
+}
+
+// Next returns the sibling following n, or nil if n is the last child of
+// its parent.
+func (n *node[U]) Next() *node[U] {
+	return n.next
+}
+
+// Children returns an iterator over the children of n, in order.
+func (n *node[U]) Children() iter.Seq[*node[U]] {
+	return func(yield func(*node[U]) bool) {
+		for child := n.up; child != nil; child = child.next {
+			if !yield(child) {
+				return
+			}
+		}
+	}
+}
+
+// FirstChild returns the first child of n matching rule, or nil if there
+// isn't one.
+func (n *node[U]) FirstChild(rule string) *node[U] {
+	for child := n.up; child != nil; child = child.next {
+		if rul3s[child.pegRule] == rule {
+			return child
+		}
+	}
+	return nil
+}
+
+// FindAll returns an iterator over n and the nodes below it matching rule,
+// depth first.
+func (n *node[U]) FindAll(rule string) iter.Seq[*node[U]] {
+	var find func(n *node[U], yield func(*node[U]) bool) bool
+	find = func(n *node[U], yield func(*node[U]) bool) bool {
+		if rul3s[n.pegRule] == rule && !yield(n) {
+			return false
+		}
+		for child := n.up; child != nil; child = child.next {
+			if !find(child, yield) {
+				return false
+			}
+		}
+		return true
+	}
+	return func(yield func(*node[U]) bool) {
+		find(n, yield)
+	}
 }
 
 func (n *node[U]) print(w io.Writer, pretty bool, buffer string) {
@@ -301,26 +475,36 @@ func (t *tokens[_]) Print() {
 }
 
 func (t *tokens[U]) AST() *node[U] {
-	type element struct {
-		node *node[U]
-		down *element
-	}
-	tokenSlice := t.Tokens()
-	var stack *element
-	for _, token := range tokenSlice {
+	return t.ast(nil)
+}
+
+// ast builds the syntax tree over the tokens, which come after the tokens
+// matched inside them. The nodes still waiting for their parent are kept
+// on a stack of links.
+func (t *tokens[U]) ast(source *nodeSource[U]) *node[U] {
+	tokens := t.Tokens()
+	tree := &syntaxTree[U]{nodes: make([]node[U], len(tokens)), source: source}
+	var stack []U
+	for i := range tokens {
+		token := &tokens[i]
 		if token.begin == token.end {
 			continue
 		}
-		node := &node[U]{token: token}
-		for stack != nil && stack.node.begin >= token.begin && stack.node.end <= token.end {
-			stack.node.next = node.up
-			node.up = stack.node
-			stack = stack.down
+		node, link := &tree.nodes[i], U(i+1)
+		node.token, node.tree = *token, tree
+		for len(stack) > 0 {
+			child := tree.node(stack[len(stack)-1])
+			if child.begin < token.begin || child.end > token.end {
+				break
+			}
+			child.next, child.nextIndex, child.parentIndex = node.up, node.upIndex, link
+			node.up, node.upIndex = child, stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
 		}
-		stack = &element{node: node, down: stack}
+		stack = append(stack, link)
 	}
-	if stack != nil {
-		return stack.node
+	if len(stack) > 0 {
+		return tree.node(stack[len(stack)-1])
 	}
 	return nil
 }
@@ -352,16 +536,535 @@ func (t *tokens[U]) Tokens() []token[U] {
 	return t.tree
 }
 
+// Visitor is called by Walk on entering and on leaving each node of a syntax
+// tree, through the methods for the rule of the node.
+type Visitor[U Uint] interface {
+	EnterGrammar(n *node[U])
+	ExitGrammar(n *node[U])
+	EnterImport(n *node[U])
+	ExitImport(n *node[U])
+	EnterSingleImport(n *node[U])
+	ExitSingleImport(n *node[U])
+	EnterMultiImport(n *node[U])
+	ExitMultiImport(n *node[U])
+	EnterImportName(n *node[U])
+	ExitImportName(n *node[U])
+	EnterInclude(n *node[U])
+	ExitInclude(n *node[U])
+	EnterDefinition(n *node[U])
+	ExitDefinition(n *node[U])
+	EnterParameter(n *node[U])
+	ExitParameter(n *node[U])
+	EnterExpression(n *node[U])
+	ExitExpression(n *node[U])
+	EnterSequence(n *node[U])
+	ExitSequence(n *node[U])
+	EnterPrefix(n *node[U])
+	ExitPrefix(n *node[U])
+	EnterSuffix(n *node[U])
+	ExitSuffix(n *node[U])
+	EnterPrimary(n *node[U])
+	ExitPrimary(n *node[U])
+	EnterArgument(n *node[U])
+	ExitArgument(n *node[U])
+	EnterIdentifier(n *node[U])
+	ExitIdentifier(n *node[U])
+	EnterTemplate(n *node[U])
+	ExitTemplate(n *node[U])
+	EnterAnnotation(n *node[U])
+	ExitAnnotation(n *node[U])
+	EnterReference(n *node[U])
+	ExitReference(n *node[U])
+	EnterCall(n *node[U])
+	ExitCall(n *node[U])
+	EnterIdentStart(n *node[U])
+	ExitIdentStart(n *node[U])
+	EnterIdentCont(n *node[U])
+	ExitIdentCont(n *node[U])
+	EnterLiteral(n *node[U])
+	ExitLiteral(n *node[U])
+	EnterClass(n *node[U])
+	ExitClass(n *node[U])
+	EnterRanges(n *node[U])
+	ExitRanges(n *node[U])
+	EnterDoubleRanges(n *node[U])
+	ExitDoubleRanges(n *node[U])
+	EnterRange(n *node[U])
+	ExitRange(n *node[U])
+	EnterDoubleRange(n *node[U])
+	ExitDoubleRange(n *node[U])
+	EnterProperty(n *node[U])
+	ExitProperty(n *node[U])
+	EnterChar(n *node[U])
+	ExitChar(n *node[U])
+	EnterDoubleChar(n *node[U])
+	ExitDoubleChar(n *node[U])
+	EnterEscape(n *node[U])
+	ExitEscape(n *node[U])
+	EnterLeftArrow(n *node[U])
+	ExitLeftArrow(n *node[U])
+	EnterSlash(n *node[U])
+	ExitSlash(n *node[U])
+	EnterAnd(n *node[U])
+	ExitAnd(n *node[U])
+	EnterNot(n *node[U])
+	ExitNot(n *node[U])
+	EnterQuestion(n *node[U])
+	ExitQuestion(n *node[U])
+	EnterStar(n *node[U])
+	ExitStar(n *node[U])
+	EnterPlus(n *node[U])
+	ExitPlus(n *node[U])
+	EnterCaret(n *node[U])
+	ExitCaret(n *node[U])
+	EnterTilde(n *node[U])
+	ExitTilde(n *node[U])
+	EnterResultType(n *node[U])
+	ExitResultType(n *node[U])
+	EnterOpen(n *node[U])
+	ExitOpen(n *node[U])
+	EnterClose(n *node[U])
+	ExitClose(n *node[U])
+	EnterComma(n *node[U])
+	ExitComma(n *node[U])
+	EnterColon(n *node[U])
+	ExitColon(n *node[U])
+	EnterDot(n *node[U])
+	ExitDot(n *node[U])
+	EnterSpaceComment(n *node[U])
+	ExitSpaceComment(n *node[U])
+	EnterSpacing(n *node[U])
+	ExitSpacing(n *node[U])
+	EnterMustSpacing(n *node[U])
+	ExitMustSpacing(n *node[U])
+	EnterComment(n *node[U])
+	ExitComment(n *node[U])
+	EnterSpace(n *node[U])
+	ExitSpace(n *node[U])
+	EnterHeader(n *node[U])
+	ExitHeader(n *node[U])
+	EnterHeaderSpaceComment(n *node[U])
+	ExitHeaderSpaceComment(n *node[U])
+	EnterHeaderComment(n *node[U])
+	ExitHeaderComment(n *node[U])
+	EnterEndOfLine(n *node[U])
+	ExitEndOfLine(n *node[U])
+	EnterEndOfFile(n *node[U])
+	ExitEndOfFile(n *node[U])
+	EnterAction(n *node[U])
+	ExitAction(n *node[U])
+	EnterActionBody(n *node[U])
+	ExitActionBody(n *node[U])
+	EnterBegin(n *node[U])
+	ExitBegin(n *node[U])
+	EnterEnd(n *node[U])
+	ExitEnd(n *node[U])
+	EnterPegText(n *node[U])
+	ExitPegText(n *node[U])
+}
+
+// BaseVisitor implements every method of Visitor by doing nothing. Embed it
+// in a visitor that only needs some of them.
+type BaseVisitor[U Uint] struct{}
+
+func (BaseVisitor[U]) EnterGrammar(*node[U])            {}
+func (BaseVisitor[U]) ExitGrammar(*node[U])             {}
+func (BaseVisitor[U]) EnterImport(*node[U])             {}
+func (BaseVisitor[U]) ExitImport(*node[U])              {}
+func (BaseVisitor[U]) EnterSingleImport(*node[U])       {}
+func (BaseVisitor[U]) ExitSingleImport(*node[U])        {}
+func (BaseVisitor[U]) EnterMultiImport(*node[U])        {}
+func (BaseVisitor[U]) ExitMultiImport(*node[U])         {}
+func (BaseVisitor[U]) EnterImportName(*node[U])         {}
+func (BaseVisitor[U]) ExitImportName(*node[U])          {}
+func (BaseVisitor[U]) EnterInclude(*node[U])            {}
+func (BaseVisitor[U]) ExitInclude(*node[U])             {}
+func (BaseVisitor[U]) EnterDefinition(*node[U])         {}
+func (BaseVisitor[U]) ExitDefinition(*node[U])          {}
+func (BaseVisitor[U]) EnterParameter(*node[U])          {}
+func (BaseVisitor[U]) ExitParameter(*node[U])           {}
+func (BaseVisitor[U]) EnterExpression(*node[U])         {}
+func (BaseVisitor[U]) ExitExpression(*node[U])          {}
+func (BaseVisitor[U]) EnterSequence(*node[U])           {}
+func (BaseVisitor[U]) ExitSequence(*node[U])            {}
+func (BaseVisitor[U]) EnterPrefix(*node[U])             {}
+func (BaseVisitor[U]) ExitPrefix(*node[U])              {}
+func (BaseVisitor[U]) EnterSuffix(*node[U])             {}
+func (BaseVisitor[U]) ExitSuffix(*node[U])              {}
+func (BaseVisitor[U]) EnterPrimary(*node[U])            {}
+func (BaseVisitor[U]) ExitPrimary(*node[U])             {}
+func (BaseVisitor[U]) EnterArgument(*node[U])           {}
+func (BaseVisitor[U]) ExitArgument(*node[U])            {}
+func (BaseVisitor[U]) EnterIdentifier(*node[U])         {}
+func (BaseVisitor[U]) ExitIdentifier(*node[U])          {}
+func (BaseVisitor[U]) EnterTemplate(*node[U])           {}
+func (BaseVisitor[U]) ExitTemplate(*node[U])            {}
+func (BaseVisitor[U]) EnterAnnotation(*node[U])         {}
+func (BaseVisitor[U]) ExitAnnotation(*node[U])          {}
+func (BaseVisitor[U]) EnterReference(*node[U])          {}
+func (BaseVisitor[U]) ExitReference(*node[U])           {}
+func (BaseVisitor[U]) EnterCall(*node[U])               {}
+func (BaseVisitor[U]) ExitCall(*node[U])                {}
+func (BaseVisitor[U]) EnterIdentStart(*node[U])         {}
+func (BaseVisitor[U]) ExitIdentStart(*node[U])          {}
+func (BaseVisitor[U]) EnterIdentCont(*node[U])          {}
+func (BaseVisitor[U]) ExitIdentCont(*node[U])           {}
+func (BaseVisitor[U]) EnterLiteral(*node[U])            {}
+func (BaseVisitor[U]) ExitLiteral(*node[U])             {}
+func (BaseVisitor[U]) EnterClass(*node[U])              {}
+func (BaseVisitor[U]) ExitClass(*node[U])               {}
+func (BaseVisitor[U]) EnterRanges(*node[U])             {}
+func (BaseVisitor[U]) ExitRanges(*node[U])              {}
+func (BaseVisitor[U]) EnterDoubleRanges(*node[U])       {}
+func (BaseVisitor[U]) ExitDoubleRanges(*node[U])        {}
+func (BaseVisitor[U]) EnterRange(*node[U])              {}
+func (BaseVisitor[U]) ExitRange(*node[U])               {}
+func (BaseVisitor[U]) EnterDoubleRange(*node[U])        {}
+func (BaseVisitor[U]) ExitDoubleRange(*node[U])         {}
+func (BaseVisitor[U]) EnterProperty(*node[U])           {}
+func (BaseVisitor[U]) ExitProperty(*node[U])            {}
+func (BaseVisitor[U]) EnterChar(*node[U])               {}
+func (BaseVisitor[U]) ExitChar(*node[U])                {}
+func (BaseVisitor[U]) EnterDoubleChar(*node[U])         {}
+func (BaseVisitor[U]) ExitDoubleChar(*node[U])          {}
+func (BaseVisitor[U]) EnterEscape(*node[U])             {}
+func (BaseVisitor[U]) ExitEscape(*node[U])              {}
+func (BaseVisitor[U]) EnterLeftArrow(*node[U])          {}
+func (BaseVisitor[U]) ExitLeftArrow(*node[U])           {}
+func (BaseVisitor[U]) EnterSlash(*node[U])              {}
+func (BaseVisitor[U]) ExitSlash(*node[U])               {}
+func (BaseVisitor[U]) EnterAnd(*node[U])                {}
+func (BaseVisitor[U]) ExitAnd(*node[U])                 {}
+func (BaseVisitor[U]) EnterNot(*node[U])                {}
+func (BaseVisitor[U]) ExitNot(*node[U])                 {}
+func (BaseVisitor[U]) EnterQuestion(*node[U])           {}
+func (BaseVisitor[U]) ExitQuestion(*node[U])            {}
+func (BaseVisitor[U]) EnterStar(*node[U])               {}
+func (BaseVisitor[U]) ExitStar(*node[U])                {}
+func (BaseVisitor[U]) EnterPlus(*node[U])               {}
+func (BaseVisitor[U]) ExitPlus(*node[U])                {}
+func (BaseVisitor[U]) EnterCaret(*node[U])              {}
+func (BaseVisitor[U]) ExitCaret(*node[U])               {}
+func (BaseVisitor[U]) EnterTilde(*node[U])              {}
+func (BaseVisitor[U]) ExitTilde(*node[U])               {}
+func (BaseVisitor[U]) EnterResultType(*node[U])         {}
+func (BaseVisitor[U]) ExitResultType(*node[U])          {}
+func (BaseVisitor[U]) EnterOpen(*node[U])               {}
+func (BaseVisitor[U]) ExitOpen(*node[U])                {}
+func (BaseVisitor[U]) EnterClose(*node[U])              {}
+func (BaseVisitor[U]) ExitClose(*node[U])               {}
+func (BaseVisitor[U]) EnterComma(*node[U])              {}
+func (BaseVisitor[U]) ExitComma(*node[U])               {}
+func (BaseVisitor[U]) EnterColon(*node[U])              {}
+func (BaseVisitor[U]) ExitColon(*node[U])               {}
+func (BaseVisitor[U]) EnterDot(*node[U])                {}
+func (BaseVisitor[U]) ExitDot(*node[U])                 {}
+func (BaseVisitor[U]) EnterSpaceComment(*node[U])       {}
+func (BaseVisitor[U]) ExitSpaceComment(*node[U])        {}
+func (BaseVisitor[U]) EnterSpacing(*node[U])            {}
+func (BaseVisitor[U]) ExitSpacing(*node[U])             {}
+func (BaseVisitor[U]) EnterMustSpacing(*node[U])        {}
+func (BaseVisitor[U]) ExitMustSpacing(*node[U])         {}
+func (BaseVisitor[U]) EnterComment(*node[U])            {}
+func (BaseVisitor[U]) ExitComment(*node[U])             {}
+func (BaseVisitor[U]) EnterSpace(*node[U])              {}
+func (BaseVisitor[U]) ExitSpace(*node[U])               {}
+func (BaseVisitor[U]) EnterHeader(*node[U])             {}
+func (BaseVisitor[U]) ExitHeader(*node[U])              {}
+func (BaseVisitor[U]) EnterHeaderSpaceComment(*node[U]) {}
+func (BaseVisitor[U]) ExitHeaderSpaceComment(*node[U])  {}
+func (BaseVisitor[U]) EnterHeaderComment(*node[U])      {}
+func (BaseVisitor[U]) ExitHeaderComment(*node[U])       {}
+func (BaseVisitor[U]) EnterEndOfLine(*node[U])          {}
+func (BaseVisitor[U]) ExitEndOfLine(*node[U])           {}
+func (BaseVisitor[U]) EnterEndOfFile(*node[U])          {}
+func (BaseVisitor[U]) ExitEndOfFile(*node[U])           {}
+func (BaseVisitor[U]) EnterAction(*node[U])             {}
+func (BaseVisitor[U]) ExitAction(*node[U])              {}
+func (BaseVisitor[U]) EnterActionBody(*node[U])         {}
+func (BaseVisitor[U]) ExitActionBody(*node[U])          {}
+func (BaseVisitor[U]) EnterBegin(*node[U])              {}
+func (BaseVisitor[U]) ExitBegin(*node[U])               {}
+func (BaseVisitor[U]) EnterEnd(*node[U])                {}
+func (BaseVisitor[U]) ExitEnd(*node[U])                 {}
+func (BaseVisitor[U]) EnterPegText(*node[U])            {}
+func (BaseVisitor[U]) ExitPegText(*node[U])             {}
+
+// Walk calls v for n and then, depth first, for each node below it.
+func Walk[U Uint](n *node[U], v Visitor[U]) {
+	if n == nil {
+		return
+	}
+	switch n.pegRule {
+	case ruleGrammar:
+		v.EnterGrammar(n)
+	case ruleImport:
+		v.EnterImport(n)
+	case ruleSingleImport:
+		v.EnterSingleImport(n)
+	case ruleMultiImport:
+		v.EnterMultiImport(n)
+	case ruleImportName:
+		v.EnterImportName(n)
+	case ruleInclude:
+		v.EnterInclude(n)
+	case ruleDefinition:
+		v.EnterDefinition(n)
+	case ruleParameter:
+		v.EnterParameter(n)
+	case ruleExpression:
+		v.EnterExpression(n)
+	case ruleSequence:
+		v.EnterSequence(n)
+	case rulePrefix:
+		v.EnterPrefix(n)
+	case ruleSuffix:
+		v.EnterSuffix(n)
+	case rulePrimary:
+		v.EnterPrimary(n)
+	case ruleArgument:
+		v.EnterArgument(n)
+	case ruleIdentifier:
+		v.EnterIdentifier(n)
+	case ruleTemplate:
+		v.EnterTemplate(n)
+	case ruleAnnotation:
+		v.EnterAnnotation(n)
+	case ruleReference:
+		v.EnterReference(n)
+	case ruleCall:
+		v.EnterCall(n)
+	case ruleIdentStart:
+		v.EnterIdentStart(n)
+	case ruleIdentCont:
+		v.EnterIdentCont(n)
+	case ruleLiteral:
+		v.EnterLiteral(n)
+	case ruleClass:
+		v.EnterClass(n)
+	case ruleRanges:
+		v.EnterRanges(n)
+	case ruleDoubleRanges:
+		v.EnterDoubleRanges(n)
+	case ruleRange:
+		v.EnterRange(n)
+	case ruleDoubleRange:
+		v.EnterDoubleRange(n)
+	case ruleProperty:
+		v.EnterProperty(n)
+	case ruleChar:
+		v.EnterChar(n)
+	case ruleDoubleChar:
+		v.EnterDoubleChar(n)
+	case ruleEscape:
+		v.EnterEscape(n)
+	case ruleLeftArrow:
+		v.EnterLeftArrow(n)
+	case ruleSlash:
+		v.EnterSlash(n)
+	case ruleAnd:
+		v.EnterAnd(n)
+	case ruleNot:
+		v.EnterNot(n)
+	case ruleQuestion:
+		v.EnterQuestion(n)
+	case ruleStar:
+		v.EnterStar(n)
+	case rulePlus:
+		v.EnterPlus(n)
+	case ruleCaret:
+		v.EnterCaret(n)
+	case ruleTilde:
+		v.EnterTilde(n)
+	case ruleResultType:
+		v.EnterResultType(n)
+	case ruleOpen:
+		v.EnterOpen(n)
+	case ruleClose:
+		v.EnterClose(n)
+	case ruleComma:
+		v.EnterComma(n)
+	case ruleColon:
+		v.EnterColon(n)
+	case ruleDot:
+		v.EnterDot(n)
+	case ruleSpaceComment:
+		v.EnterSpaceComment(n)
+	case ruleSpacing:
+		v.EnterSpacing(n)
+	case ruleMustSpacing:
+		v.EnterMustSpacing(n)
+	case ruleComment:
+		v.EnterComment(n)
+	case ruleSpace:
+		v.EnterSpace(n)
+	case ruleHeader:
+		v.EnterHeader(n)
+	case ruleHeaderSpaceComment:
+		v.EnterHeaderSpaceComment(n)
+	case ruleHeaderComment:
+		v.EnterHeaderComment(n)
+	case ruleEndOfLine:
+		v.EnterEndOfLine(n)
+	case ruleEndOfFile:
+		v.EnterEndOfFile(n)
+	case ruleAction:
+		v.EnterAction(n)
+	case ruleActionBody:
+		v.EnterActionBody(n)
+	case ruleBegin:
+		v.EnterBegin(n)
+	case ruleEnd:
+		v.EnterEnd(n)
+	case rulePegText:
+		v.EnterPegText(n)
+	}
+	for child := n.up; child != nil; child = child.next {
+		Walk(child, v)
+	}
+	switch n.pegRule {
+	case ruleGrammar:
+		v.ExitGrammar(n)
+	case ruleImport:
+		v.ExitImport(n)
+	case ruleSingleImport:
+		v.ExitSingleImport(n)
+	case ruleMultiImport:
+		v.ExitMultiImport(n)
+	case ruleImportName:
+		v.ExitImportName(n)
+	case ruleInclude:
+		v.ExitInclude(n)
+	case ruleDefinition:
+		v.ExitDefinition(n)
+	case ruleParameter:
+		v.ExitParameter(n)
+	case ruleExpression:
+		v.ExitExpression(n)
+	case ruleSequence:
+		v.ExitSequence(n)
+	case rulePrefix:
+		v.ExitPrefix(n)
+	case ruleSuffix:
+		v.ExitSuffix(n)
+	case rulePrimary:
+		v.ExitPrimary(n)
+	case ruleArgument:
+		v.ExitArgument(n)
+	case ruleIdentifier:
+		v.ExitIdentifier(n)
+	case ruleTemplate:
+		v.ExitTemplate(n)
+	case ruleAnnotation:
+		v.ExitAnnotation(n)
+	case ruleReference:
+		v.ExitReference(n)
+	case ruleCall:
+		v.ExitCall(n)
+	case ruleIdentStart:
+		v.ExitIdentStart(n)
+	case ruleIdentCont:
+		v.ExitIdentCont(n)
+	case ruleLiteral:
+		v.ExitLiteral(n)
+	case ruleClass:
+		v.ExitClass(n)
+	case ruleRanges:
+		v.ExitRanges(n)
+	case ruleDoubleRanges:
+		v.ExitDoubleRanges(n)
+	case ruleRange:
+		v.ExitRange(n)
+	case ruleDoubleRange:
+		v.ExitDoubleRange(n)
+	case ruleProperty:
+		v.ExitProperty(n)
+	case ruleChar:
+		v.ExitChar(n)
+	case ruleDoubleChar:
+		v.ExitDoubleChar(n)
+	case ruleEscape:
+		v.ExitEscape(n)
+	case ruleLeftArrow:
+		v.ExitLeftArrow(n)
+	case ruleSlash:
+		v.ExitSlash(n)
+	case ruleAnd:
+		v.ExitAnd(n)
+	case ruleNot:
+		v.ExitNot(n)
+	case ruleQuestion:
+		v.ExitQuestion(n)
+	case ruleStar:
+		v.ExitStar(n)
+	case rulePlus:
+		v.ExitPlus(n)
+	case ruleCaret:
+		v.ExitCaret(n)
+	case ruleTilde:
+		v.ExitTilde(n)
+	case ruleResultType:
+		v.ExitResultType(n)
+	case ruleOpen:
+		v.ExitOpen(n)
+	case ruleClose:
+		v.ExitClose(n)
+	case ruleComma:
+		v.ExitComma(n)
+	case ruleColon:
+		v.ExitColon(n)
+	case ruleDot:
+		v.ExitDot(n)
+	case ruleSpaceComment:
+		v.ExitSpaceComment(n)
+	case ruleSpacing:
+		v.ExitSpacing(n)
+	case ruleMustSpacing:
+		v.ExitMustSpacing(n)
+	case ruleComment:
+		v.ExitComment(n)
+	case ruleSpace:
+		v.ExitSpace(n)
+	case ruleHeader:
+		v.ExitHeader(n)
+	case ruleHeaderSpaceComment:
+		v.ExitHeaderSpaceComment(n)
+	case ruleHeaderComment:
+		v.ExitHeaderComment(n)
+	case ruleEndOfLine:
+		v.ExitEndOfLine(n)
+	case ruleEndOfFile:
+		v.ExitEndOfFile(n)
+	case ruleAction:
+		v.ExitAction(n)
+	case ruleActionBody:
+		v.ExitActionBody(n)
+	case ruleBegin:
+		v.ExitBegin(n)
+	case ruleEnd:
+		v.ExitEnd(n)
+	case rulePegText:
+		v.ExitPegText(n)
+	}
+}
+
 type Peg[U Uint] struct {
 	*tree.Tree
 
 	Buffer         string
 	buffer         []rune
-	rules          [101]func() bool
+	rules          [130]func() bool
 	parse          func(rule ...int) error
 	reset          func()
+	edit           func(start, oldEnd int, text string) error
 	Pretty         bool
+	lineIndex      lineIndex
+	ctx            context.Context
+	maxSteps       int
+	maxDepth       int
+	profile        []RuleProfile
 	disableMemoize bool
+	sparseMemo     bool
 	tokens[U]
 }
 
@@ -369,76 +1072,158 @@ func (p *Peg[_]) Parse(rule ...int) error {
 	return p.parse(rule...)
 }
 
+// ParseContext is Parse, stopping with the error of ctx once ctx is done.
+func (p *Peg[_]) ParseContext(ctx context.Context, rule ...int) error {
+	p.ctx = ctx
+	defer func() { p.ctx = nil }()
+	return p.parse(rule...)
+}
+
 func (p *Peg[_]) Reset() {
 	p.reset()
 }
 
-type textPosition struct {
-	line, symbol int
+// AST returns the syntax tree of the last parse. Unlike the trees of
+// tokens.AST, its nodes know their text.
+func (p *Peg[U]) AST() *node[U] {
+	return p.tokens.ast(&nodeSource[U]{buffer: p.buffer})
+}
+
+// Edit replaces the input from start to oldEnd with text, where start and
+// oldEnd are positions like those of the tokens, and resets the parser. The
+// memoized results that didn't look at the replaced input are kept, shifted
+// past the new text, and reused by the next Parse. If start and oldEnd
+// aren't in the input, or start is after oldEnd, Edit returns an error and
+// leaves the parser as it is.
+func (p *Peg[_]) Edit(start, oldEnd int, text string) error {
+	return p.edit(start, oldEnd, text)
 }
 
-type textPositionMap map[int]textPosition
+// Position translates offset, a position like those of the tokens and of
+// begin and end in the actions, into a line and a column. The lines of the
+// input are indexed by the first call, so the calls are cheap.
+func (p *Peg[_]) Position(offset int) Position {
+	return p.lineIndex.translate(p.buffer, offset)
+}
 
-func translatePositions(buffer []rune, positions []int) textPositionMap {
-	length := len(positions)
-	translations := make(textPositionMap, length)
-	posIdx := 0
-	line := 1
-	symbol := 0
+// Position is a location in the parsed input. Lines and columns start at 1,
+// columns are counted in runes.
+type Position struct {
+	Offset int
+	Rune   int
+	Line   int
+	Column int
+}
 
-	slices.Sort(positions)
+// lineIndex translates the positions of a buffer into lines and columns. It
+// marks where each line starts, and every 256 runes along long lines, so a
+// translation only counts the runes from the closest mark. The buffer is
+// indexed as far as it goes on the first translation, and afterwards only
+// where it has grown.
+type lineIndex struct {
+	marks []Position
+	last  Position
+}
 
-	for i, c := range buffer {
+func (x *lineIndex) translate(buffer []rune, i int) Position {
+	if len(x.marks) == 0 {
+		x.last = Position{Line: 1, Column: 1}
+		x.marks = append(x.marks, x.last)
+	}
+	for x.last.Rune < len(buffer) && buffer[x.last.Rune] != endSymbol {
+		c := buffer[x.last.Rune]
+		x.last.Offset, x.last.Rune, x.last.Column = x.last.Offset+utf8.RuneLen(c), x.last.Rune+1, x.last.Column+1
 		if c == '\n' {
-			line, symbol = line+1, 0
-		} else {
-			symbol++
-		}
-		if i == positions[posIdx] {
-			translations[positions[posIdx]] = textPosition{line, symbol}
-			for posIdx++; posIdx < length; posIdx++ {
-				if i == positions[posIdx] {
-					return translations
-				}
-			}
+			x.last.Line, x.last.Column = x.last.Line+1, 1
 		}
-		if posIdx >= length {
-			break
+		if c == '\n' || x.last.Rune%256 == 0 {
+			x.marks = append(x.marks, x.last)
 		}
 	}
-
-	return translations
+	i = min(max(i, 0), x.last.Rune)
+	k, found := slices.BinarySearchFunc(x.marks, i, func(m Position, i int) int { return m.Rune - i })
+	if !found {
+		k--
+	}
+	position := x.marks[k]
+	for _, c := range buffer[position.Rune:i] {
+		position.Offset += utf8.RuneLen(c)
+	}
+	position.Rune, position.Column = i, position.Column+i-position.Rune
+	return position
 }
 
-type parseError[U Uint] struct {
-	p        *Peg[U]
-	maxToken token[U]
+// ParseError is returned by Parse when the input doesn't match the grammar.
+// Position is the farthest position the parser reached and Expected lists
+// what would have been accepted there. Rule is the last rule matched before
+// the failure, spanning Begin to End and matching Text. Snippet is the line
+// of input containing Position.
+type ParseError struct {
+	Position
+	Expected   []string
+	Rule       string
+	Begin, End Position
+	Text       string
+	Snippet    string
+	pretty     bool
 }
 
-func (e *parseError[U]) Error() string {
-	tokenSlice, err := []token[U]{e.maxToken}, "\n"
-	positions, p := make([]int, 2*len(tokenSlice)), 0
-	for _, t := range tokenSlice {
-		positions[p], p = int(t.begin), p+1
-		positions[p], p = int(t.end), p+1
+func (p *Peg[U]) newParseError(maxToken token[U], farthest U, expected []string) *ParseError {
+	begin, end, at := int(maxToken.begin), int(maxToken.end), int(farthest)
+	position, from, to := p.Position(at), p.Position(begin), p.Position(end)
+	start := at
+	for start > 0 && p.buffer[start-1] != '\n' {
+		start--
 	}
-	translations := translatePositions(e.p.buffer, positions)
-	format := "parse error near %v (line %v symbol %v - line %v symbol %v):\n%v\n"
-	if e.p.Pretty {
-		format = "parse error near \x1B[34m%v\x1B[m (line %v symbol %v - line %v symbol %v):\n%v\n"
+	line := p.buffer[start:]
+	if i := slices.Index(line, '\n'); i >= 0 {
+		line = line[:i]
 	}
-	for _, t := range tokenSlice {
-		begin, end := int(t.begin), int(t.end)
-		err += fmt.Sprintf(format,
-			rul3s[t.pegRule],
-			translations[begin].line, translations[begin].symbol,
-			translations[end].line, translations[end].symbol,
-			strconv.Quote(string(e.p.buffer[begin:end])))
+	if len(line) > 0 && line[len(line)-1] == endSymbol {
+		line = line[:len(line)-1]
+	}
+	return &ParseError{
+		Position: position,
+		Expected: expected,
+		Rule:     rul3s[maxToken.pegRule],
+		Begin:    from,
+		End:      to,
+		Text:     string(p.buffer[begin:end]),
+		Snippet:  string(line),
+		pretty:   p.Pretty,
+	}
+}
+
+func (e *ParseError) Error() string {
+	format := "\nparse error near %v (line %v symbol %v - line %v symbol %v):\n%v\n"
+	if e.pretty {
+		format = "\nparse error near \x1B[34m%v\x1B[m (line %v symbol %v - line %v symbol %v):\n%v\n"
+	}
+	err := fmt.Sprintf(format,
+		e.Rule,
+		e.Begin.Line, e.Begin.Column,
+		e.End.Line, e.End.Column,
+		strconv.Quote(e.Text))
+	if len(e.Expected) > 0 {
+		err += fmt.Sprintf("expected %v at line %v col %v\n", expectedList(e.Expected), e.Line, e.Column)
 	}
 
 	return err
 }
 
+func expectedList(expected []string) string {
+	var unique []string
+	for _, what := range expected {
+		if !slices.Contains(unique, what) {
+			unique = append(unique, what)
+		}
+	}
+	if len(unique) == 1 {
+		return unique[0]
+	}
+	return strings.Join(unique[:len(unique)-1], ", ") + " or " + unique[len(unique)-1]
+}
+
 func (p *Peg[_]) PrintSyntaxTree() {
 	if p.Pretty {
 		p.tokens.PrettyPrintSyntaxTree(p.Buffer)
@@ -477,103 +1262,136 @@ func (p *Peg[_]) Execute() {
 		case ruleAction4:
 			p.AddImport(text)
 		case ruleAction5:
-			p.AddRule(text)
+			p.AddIncludeNamespace(text)
 		case ruleAction6:
-			p.AddExpression()
+			p.AddInclude(text)
 		case ruleAction7:
-			p.AddAlternate()
+			p.AddAnnotation(text)
 		case ruleAction8:
+			p.AddRule(text)
+		case ruleAction9:
+			p.AddRule(text)
+		case ruleAction10:
+			p.AddResultType(text)
+		case ruleAction11:
+			p.AddExpression()
+		case ruleAction12:
+			p.AddParameter(text)
+		case ruleAction13:
+			p.AddAlternate()
+		case ruleAction14:
 			p.AddNil()
 			p.AddAlternate()
-		case ruleAction9:
+		case ruleAction15:
 			p.AddNil()
-		case ruleAction10:
+		case ruleAction16:
 			p.AddSequence()
-		case ruleAction11:
+		case ruleAction17:
 			p.AddPredicate(text)
-		case ruleAction12:
+		case ruleAction18:
 			p.AddStateChange(text)
-		case ruleAction13:
+		case ruleAction19:
 			p.AddPeekFor()
-		case ruleAction14:
+		case ruleAction20:
 			p.AddPeekNot()
-		case ruleAction15:
+		case ruleAction21:
+			p.AddCommit()
+		case ruleAction22:
+			p.AddLabel(text)
+		case ruleAction23:
+			p.AddLabeled()
+		case ruleAction24:
 			p.AddQuery()
-		case ruleAction16:
+		case ruleAction25:
 			p.AddStar()
-		case ruleAction17:
+		case ruleAction26:
 			p.AddPlus()
-		case ruleAction18:
+		case ruleAction27:
+			p.AddRecovery(text)
+		case ruleAction28:
 			p.AddName(text)
-		case ruleAction19:
+		case ruleAction29:
+			p.AddName(text)
+		case ruleAction30:
 			p.AddDot()
-		case ruleAction20:
+		case ruleAction31:
 			p.AddAction(text)
-		case ruleAction21:
+		case ruleAction32:
+			p.AddCapture(text)
+		case ruleAction33:
+			p.AddCaptured()
+		case ruleAction34:
 			p.AddPush()
-		case ruleAction22:
+		case ruleAction35:
+			p.AddArgument()
+		case ruleAction36:
 			p.AddSequence()
-		case ruleAction23:
+		case ruleAction37:
 			p.AddSequence()
-		case ruleAction24:
+		case ruleAction38:
+			p.AddCaseInsensitive()
+		case ruleAction39:
 			p.AddPeekNot()
 			p.AddDot()
 			p.AddSequence()
-		case ruleAction25:
+		case ruleAction40:
 			p.AddPeekNot()
 			p.AddDot()
 			p.AddSequence()
-		case ruleAction26:
+		case ruleAction41:
 			p.AddAlternate()
-		case ruleAction27:
+		case ruleAction42:
 			p.AddAlternate()
-		case ruleAction28:
+		case ruleAction43:
 			p.AddRange()
-		case ruleAction29:
+		case ruleAction44:
 			p.AddDoubleRange()
-		case ruleAction30:
+		case ruleAction45:
+			p.AddProperty(text)
+		case ruleAction46:
 			p.AddCharacter(text)
-		case ruleAction31:
-			p.AddDoubleCharacter(text)
-		case ruleAction32:
+		case ruleAction47:
 			p.AddCharacter(text)
-		case ruleAction33:
+			p.AddCaseInsensitive()
+		case ruleAction48:
 			p.AddCharacter("\a")
-		case ruleAction34:
+		case ruleAction49:
 			p.AddCharacter("\b")
-		case ruleAction35:
+		case ruleAction50:
 			p.AddCharacter("\x1B")
-		case ruleAction36:
+		case ruleAction51:
 			p.AddCharacter("\f")
-		case ruleAction37:
+		case ruleAction52:
 			p.AddCharacter("\n")
-		case ruleAction38:
+		case ruleAction53:
 			p.AddCharacter("\r")
-		case ruleAction39:
+		case ruleAction54:
 			p.AddCharacter("\t")
-		case ruleAction40:
+		case ruleAction55:
 			p.AddCharacter("\v")
-		case ruleAction41:
+		case ruleAction56:
 			p.AddCharacter("'")
-		case ruleAction42:
+		case ruleAction57:
 			p.AddCharacter("\"")
-		case ruleAction43:
+		case ruleAction58:
 			p.AddCharacter("[")
-		case ruleAction44:
+		case ruleAction59:
 			p.AddCharacter("]")
-		case ruleAction45:
+		case ruleAction60:
 			p.AddCharacter("-")
-		case ruleAction46:
+		case ruleAction61:
 			p.AddHexaCharacter(text)
-		case ruleAction47:
+		case ruleAction62:
+			p.AddHexaCharacter(text)
+		case ruleAction63:
 			p.AddOctalCharacter(text)
-		case ruleAction48:
+		case ruleAction64:
 			p.AddOctalCharacter(text)
-		case ruleAction49:
+		case ruleAction65:
 			p.AddCharacter("\\")
-		case ruleAction50:
+		case ruleAction66:
 			p.AddSpace(text)
-		case ruleAction51:
+		case ruleAction67:
 			p.AddComment(text)
 
 		}
@@ -588,6 +1406,104 @@ func Pretty[U Uint](pretty bool) func(*Peg[U]) error {
 	}
 }
 
+// ErrMaxSteps is returned by a parse that runs out of the steps allowed by
+// MaxSteps.
+var ErrMaxSteps = errors.New("parse exceeded the maximum number of steps")
+
+// MaxSteps stops a parse with ErrMaxSteps once it takes more than n steps,
+// where a step is running a rule or repeating an expression. Zero means no
+// limit.
+func MaxSteps[U Uint](n int) func(*Peg[U]) error {
+	return func(p *Peg[U]) error {
+		if n < 0 {
+			return fmt.Errorf("negative maximum number of steps %d", n)
+		}
+		p.maxSteps = n
+		return nil
+	}
+}
+
+// DepthError is returned by a parse with rules nested deeper than allowed by
+// MaxDepth. Rule is the rule that would have gone too deep, at Position.
+type DepthError struct {
+	Position
+	Rule     string
+	MaxDepth int
+}
+
+func (e *DepthError) Error() string {
+	return fmt.Sprintf("rules nested more than %d deep in %v at line %d col %d", e.MaxDepth, e.Rule, e.Line, e.Column)
+}
+
+func (p *Peg[U]) newDepthError(rule pegRule, at U) *DepthError {
+	return &DepthError{p.Position(int(at)), rul3s[rule], p.maxDepth}
+}
+
+// MaxDepth stops a parse with a *DepthError before its rules nest more than
+// n deep, which keeps deeply nested input from overflowing the stack. Zero
+// means no limit.
+func MaxDepth[U Uint](n int) func(*Peg[U]) error {
+	return func(p *Peg[U]) error {
+		if n < 0 {
+			return fmt.Errorf("negative maximum depth %d", n)
+		}
+		p.maxDepth = n
+		return nil
+	}
+}
+
+// RuleProfile counts what a rule did in the parses of a parser profiling
+// its rules. Calls is Matches plus Failures, MemoHits counts the calls
+// answered by the memoized result of an earlier one, and Reparses the calls
+// parsing a position the rule had already parsed in the same parse. Time
+// is the time spent in the rule, including the rules it called.
+type RuleProfile struct {
+	Rule     string
+	Calls    int
+	Matches  int
+	Failures int
+	MemoHits int
+	Reparses int
+	Time     time.Duration
+}
+
+// EnableProfiling makes the parser count what each of its rules does, for
+// Profile. The rules inlined by -inline are counted as part of the rules
+// using them.
+func EnableProfiling[U Uint]() func(*Peg[U]) error {
+	return func(p *Peg[U]) error {
+		p.profile = make([]RuleProfile, len(rul3s))
+		return nil
+	}
+}
+
+// Profile returns what the rules called since Init with EnableProfiling
+// did, starting with the rules the parses spent the most time in.
+func (p *Peg[_]) Profile() []RuleProfile {
+	var profile []RuleProfile
+	for i, r := range p.profile {
+		if r.Calls > 0 {
+			r.Rule = rul3s[i]
+			profile = append(profile, r)
+		}
+	}
+	slices.SortStableFunc(profile, func(a, b RuleProfile) int {
+		switch {
+		case a.Time > b.Time:
+			return -1
+		case a.Time < b.Time:
+			return 1
+		}
+		return b.Calls - a.Calls
+	})
+	return profile
+}
+
+// parsedKey is a rule and a position it parsed, for profiling.
+type parsedKey struct {
+	rule, position int
+}
+
 func Size[U Uint](size int) func(*Peg[U]) error {
 	return func(p *Peg[U]) error {
 		p.tokens = tokens[U]{tree: make([]token[U], 0, size)}
@@ -602,9 +1518,21 @@ func DisableMemoize[U Uint]() func(*Peg[U]) error {
 	}
 }
 
+// SparseMemo keeps the memoized results in a map keyed by rule and
+// position instead of the dense table, cloning the tokens of each match.
+// The table takes memory for every position up to the farthest one
+// memoized, so the map can be smaller when few positions are.
+func SparseMemo[U Uint]() func(*Peg[U]) error {
+	return func(p *Peg[U]) error {
+		p.sparseMemo = true
+		return nil
+	}
+}
+
 type memo[U Uint] struct {
 	Matched bool
 	Partial []token[U]
+	Reach   U
 }
 
 type memoKey[U Uint] struct {
@@ -612,45 +1540,421 @@ type memoKey[U Uint] struct {
 	Position U
 }
 
-func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
-	var (
-		maxToken             token[U]
-		position, tokenIndex U
-		buffer               []rune
-		memoization          map[memoKey[U]]memo[U]
-	)
-	for _, option := range options {
-		err := option(p)
-		if err != nil {
-			return err
+// memoStore holds the memoized results of the rules. set keeps a copy of
+// the tokens of the result, the ones returned are only valid until the
+// store is next changed.
+type memoStore[U Uint] interface {
+	get(key memoKey[U]) (memo[U], bool)
+	set(key memoKey[U], m memo[U])
+	delete(key memoKey[U])
+	deleteBefore(limit U)
+	all() iter.Seq2[memoKey[U], memo[U]]
+	clear()
+}
+
+// memoMap is the memoStore of SparseMemo.
+type memoMap[U Uint] map[memoKey[U]]memo[U]
+
+func (t memoMap[U]) get(key memoKey[U]) (memo[U], bool) {
+	m, ok := t[key]
+	return m, ok
+}
+
+func (t memoMap[U]) set(key memoKey[U], m memo[U]) {
+	m.Partial = slices.Clone(m.Partial)
+	t[key] = m
+}
+
+func (t memoMap[U]) delete(key memoKey[U]) {
+	delete(t, key)
+}
+
+func (t memoMap[U]) deleteBefore(limit U) {
+	for key := range t {
+		if key.Position < limit {
+			delete(t, key)
 		}
 	}
-	p.reset = func() {
-		maxToken = token[U]{}
-		position, tokenIndex = 0, 0
-		memoization = make(map[memoKey[U]]memo[U])
-		p.buffer = []rune(p.Buffer)
-		if len(p.buffer) == 0 || p.buffer[len(p.buffer)-1] != endSymbol {
-			p.buffer = append(p.buffer, endSymbol)
+}
+
+func (t memoMap[U]) all() iter.Seq2[memoKey[U], memo[U]] {
+	return func(yield func(memoKey[U], memo[U]) bool) {
+		for key, m := range t {
+			if !yield(key, m) {
+				return
+			}
 		}
-		buffer = p.buffer
 	}
-	p.reset()
+}
 
-	_rules := p.rules
-	tree := p.tokens
-	p.parse = func(rule ...int) error {
-		r := 1
-		if len(rule) > 0 {
-			r = rule[0]
-		}
-		matches := p.rules[r]()
-		p.tokens = tree
-		if matches {
-			p.Trim(uint32(tokenIndex))
+func (t memoMap[U]) clear() {
+	clear(t)
+}
+
+// memoTable is the dense memoStore, a packrat table. The results memoized
+// at a position are chained from heads, indexed by the position from start,
+// through the entries slab, and the tokens of all of them are kept one
+// after the other in arena instead of in slices of their own. The links
+// are entry indexes plus one, so that zero ends a chain, and like the
+// ranges of arena they are int32 to keep the entries small. dead counts
+// the tokens of arena no entry holds any more, and spareEntries and
+// spareArena are the slabs before the last compaction, kept for the next
+// one to copy into.
+type memoTable[U Uint] struct {
+	start        U
+	heads        []int32
+	entries      []memoEntry[U]
+	arena        []token[U]
+	spareEntries []memoEntry[U]
+	spareArena   []token[U]
+	dead         int
+	compacted    int
+}
+
+type memoEntry[U Uint] struct {
+	rule       U
+	matched    bool
+	reach      U
+	begin, end int32
+	next       int32
+}
+
+func (t *memoTable[U]) result(e *memoEntry[U]) memo[U] {
+	return memo[U]{Matched: e.matched, Partial: t.arena[e.begin:e.end:e.end], Reach: e.reach}
+}
+
+// link returns the link to the entry of rule at position i of heads, or to
+// the zero ending its chain.
+func (t *memoTable[U]) link(i int, rule U) *int32 {
+	link := &t.heads[i]
+	for *link != 0 && t.entries[*link-1].rule != rule {
+		link = &t.entries[*link-1].next
+	}
+	return link
+}
+
+func (t *memoTable[U]) get(key memoKey[U]) (memo[U], bool) {
+	if key.Position < t.start || int(key.Position-t.start) >= len(t.heads) {
+		return memo[U]{}, false
+	}
+	if link := t.link(int(key.Position-t.start), key.Rule); *link != 0 {
+		return t.result(&t.entries[*link-1]), true
+	}
+	return memo[U]{}, false
+}
+
+func (t *memoTable[U]) set(key memoKey[U], m memo[U]) {
+	if key.Position < t.start {
+		return
+	}
+	i := int(key.Position - t.start)
+	if n := len(t.heads); i >= n {
+		t.heads = slices.Grow(t.heads, i+1-n)[:i+1]
+		clear(t.heads[n:])
+	}
+	if link := t.link(i, key.Rule); *link != 0 {
+		/* a seed being grown or a result parsed again: its tokens are
+		   copied over the old ones if they fit */
+		entry := &t.entries[*link-1]
+		entry.matched, entry.reach = m.Matched, m.Reach
+		if n := int32(len(m.Partial)); n <= entry.end-entry.begin {
+			copy(t.arena[entry.begin:], m.Partial)
+			t.dead += int(entry.end - entry.begin - n)
+			entry.end = entry.begin + n
+			return
+		}
+		t.dead += int(entry.end - entry.begin)
+		entry.begin, entry.end = t.add(m.Partial)
+		/* compacting costs the tokens kept and the positions, which the
+		   tokens dropped pay for once they outnumber them */
+		if t.dead > len(t.arena)-t.dead+len(t.heads) {
+			t.compact()
+		}
+		return
+	}
+	entry := memoEntry[U]{rule: key.Rule, matched: m.Matched, reach: m.Reach, next: t.heads[i]}
+	entry.begin, entry.end = t.add(m.Partial)
+	if len(t.entries) == cap(t.entries) {
+		t.entries = append(make([]memoEntry[U], 0, max(2*cap(t.entries), 1024)), t.entries...)
+	}
+	t.entries = append(t.entries, entry)
+	t.heads[i] = int32(len(t.entries))
+}
+
+// add copies partial to the end of arena and returns its range. arena is
+// doubled when full, so that filling it allocates about twice what it ends
+// up holding, where append would allocate up to five times as much.
+func (t *memoTable[U]) add(partial []token[U]) (int32, int32) {
+	begin := len(t.arena)
+	if begin+len(partial) > cap(t.arena) {
+		t.arena = append(make([]token[U], 0, max(2*cap(t.arena), begin+len(partial), 1024)), t.arena...)
+	}
+	t.arena = append(t.arena, partial...)
+	return int32(begin), int32(len(t.arena))
+}
+
+func (t *memoTable[U]) delete(key memoKey[U]) {
+	if key.Position < t.start || int(key.Position-t.start) >= len(t.heads) {
+		return
+	}
+	if link := t.link(int(key.Position-t.start), key.Rule); *link != 0 {
+		entry := &t.entries[*link-1]
+		t.dead += int(entry.end - entry.begin)
+		*link = entry.next
+	}
+}
+
+// deleteBefore drops the positions before limit, and the entries and tokens
+// left unreachable once they are as many as the ones kept by the last
+// compaction.
+func (t *memoTable[U]) deleteBefore(limit U) {
+	if limit <= t.start {
+		return
+	}
+	t.heads = t.heads[min(int(limit-t.start), len(t.heads)):]
+	t.start = limit
+	if len(t.entries) < 2*t.compacted+1024 {
+		return
+	}
+	t.compact()
+}
+
+// compact copies the entries and tokens that can still be reached one after
+// the other, leaving out the rest.
+func (t *memoTable[U]) compact() {
+	entries, arena := t.spareEntries[:0], t.spareArena[:0]
+	for i, e := range t.heads {
+		t.heads[i] = 0
+		for ; e != 0; e = t.entries[e-1].next {
+			entry := t.entries[e-1]
+			begin := int32(len(arena))
+			arena = append(arena, t.arena[entry.begin:entry.end]...)
+			entry.begin, entry.end, entry.next = begin, int32(len(arena)), t.heads[i]
+			entries = append(entries, entry)
+			t.heads[i] = int32(len(entries))
+		}
+	}
+	t.spareEntries, t.spareArena = t.entries[:0], t.arena[:0]
+	t.entries, t.arena, t.dead, t.compacted = entries, arena, 0, len(entries)
+}
+
+func (t *memoTable[U]) all() iter.Seq2[memoKey[U], memo[U]] {
+	return func(yield func(memoKey[U], memo[U]) bool) {
+		for i, e := range t.heads {
+			for ; e != 0; e = t.entries[e-1].next {
+				entry := &t.entries[e-1]
+				if !yield(memoKey[U]{entry.rule, t.start + U(i)}, t.result(entry)) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func (t *memoTable[U]) clear() {
+	t.start, t.heads, t.entries, t.arena, t.dead, t.compacted = 0, t.heads[:0], t.entries[:0], t.arena[:0], 0, 0
+}
+
+func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
+	var (
+		maxToken             token[U]
+		position, tokenIndex U
+		buffer               []rune
+		start                pegRule
+		farthest             U
+		expected             []string
+		silent               int
+		steps, due           int
+		limited              bool
+		stopped              error
+		memoization          memoStore[U]
+		reach                U
+		edited               bool
+		parsed               map[parsedKey]bool
+	)
+	for _, option := range options {
+		err := option(p)
+		if err != nil {
+			return err
+		}
+	}
+	p.reset = func() {
+		maxToken = token[U]{}
+		position, tokenIndex = 0, 0
+		farthest, expected, silent = 0, expected[:0], 0
+		switch {
+		case memoization != nil:
+			memoization.clear()
+		case p.sparseMemo:
+			memoization = memoMap[U]{}
+		default:
+			memoization = &memoTable[U]{}
+		}
+		reach = 0
+		edited = false
+		clear(parsed)
+		p.buffer = []rune(p.Buffer)
+		if len(p.buffer) == 0 || p.buffer[len(p.buffer)-1] != endSymbol {
+			p.buffer = append(p.buffer, endSymbol)
+		}
+		buffer, p.lineIndex = p.buffer, lineIndex{marks: p.lineIndex.marks[:0]}
+	}
+	p.reset()
+
+	p.edit = func(start, oldEnd int, text string) error {
+		length := len(buffer) - 1
+		if start < 0 || start > oldEnd || oldEnd > length {
+			return fmt.Errorf("invalid edit from %d to %d of an input of length %d", start, oldEnd, length)
+		}
+		delta := utf8.RuneCountInString(text) - (oldEnd - start)
+		p.Buffer = string(buffer[:start]) + text + string(buffer[oldEnd:len(buffer)-1])
+		shift := func(u U) U { return U(int(u) + delta) }
+		old := memoization
+		memoization = nil
+		p.reset()
+		for key, m := range old.all() {
+			begin := int(key.Position)
+			switch {
+			case int(m.Reach) <= start:
+				memoization.set(key, m)
+			case begin >= oldEnd && begin > start:
+				for i := range m.Partial {
+					m.Partial[i].begin, m.Partial[i].end = shift(m.Partial[i].begin), shift(m.Partial[i].end)
+				}
+				m.Reach = shift(m.Reach)
+				memoization.set(memoKey[U]{key.Rule, shift(key.Position)}, m)
+			}
+		}
+		edited = true
+		return nil
+	}
+
+	_rules := p.rules
+	tree := p.tokens
+	p.parse = func(rule ...int) error {
+		r := 1
+		if len(rule) > 0 {
+			r = rule[0]
+		}
+		start = pegRule(r)
+		/* the rules only count their steps when there is a limit or a
+		   context to check */
+		steps, due, stopped = 0, 0, nil
+		limited = p.maxSteps > 0 || p.ctx != nil
+		matches := p.rules[r]()
+		if stopped != nil {
+			/* the results of a parse that was stopped can't be trusted */
+			p.reset()
+			return stopped
+		}
+		p.tokens = tree
+		if matches {
+			p.Trim(uint32(tokenIndex))
 			return nil
 		}
-		return &parseError[U]{p, maxToken}
+		return p.newParseError(maxToken, farthest, slices.Clone(expected))
+	}
+
+	parse := p.parse
+	p.parse = func(rule ...int) error {
+		err := parse(rule...)
+		if err != nil && edited && stopped == nil {
+			/* the results kept by Edit don't report the failures in them
+			   again, so the errors come from parsing from scratch */
+			p.reset()
+			err = parse(rule...)
+		}
+		edited = false
+		return err
+	}
+
+	// step counts the steps of the parse and stops it once it runs out of
+	// steps or its context is done, which is checked every so often.
+	step := func() bool {
+		steps++
+		if steps < due {
+			return true
+		}
+		if stopped == nil {
+			if p.maxSteps > 0 && steps > p.maxSteps {
+				stopped = ErrMaxSteps
+			} else if p.ctx != nil {
+				stopped = p.ctx.Err()
+			}
+		}
+		if stopped != nil {
+			return false
+		}
+		due = steps + 1024
+		if p.maxSteps > 0 {
+			due = min(due, p.maxSteps+1)
+		}
+		return true
+	}
+
+	// profileRules wraps the rules to count what they do, if the parser is
+	// profiling them.
+	profileRules := func() {
+		if p.profile == nil {
+			return
+		}
+		parsed = make(map[parsedKey]bool)
+		for i, rule := range _rules {
+			if rule == nil {
+				continue
+			}
+			switch pegRule(i) {
+			case ruleAction0, ruleAction1, ruleAction2, ruleAction3, ruleAction4, ruleAction5, ruleAction6, ruleAction7, ruleAction8, ruleAction9, ruleAction10, ruleAction11, ruleAction12, ruleAction13, ruleAction14, ruleAction15, ruleAction16, ruleAction17, ruleAction18, ruleAction19, ruleAction20, ruleAction21, ruleAction22, ruleAction23, ruleAction24, ruleAction25, ruleAction26, ruleAction27, ruleAction28, ruleAction29, ruleAction30, ruleAction31, ruleAction32, ruleAction33, ruleAction34, ruleAction35, ruleAction36, ruleAction37, ruleAction38, ruleAction39, ruleAction40, ruleAction41, ruleAction42, ruleAction43, ruleAction44, ruleAction45, ruleAction46, ruleAction47, ruleAction48, ruleAction49, ruleAction50, ruleAction51, ruleAction52, ruleAction53, ruleAction54, ruleAction55, ruleAction56, ruleAction57, ruleAction58, ruleAction59, ruleAction60, ruleAction61, ruleAction62, ruleAction63, ruleAction64, ruleAction65, ruleAction66, ruleAction67:
+				/* the actions aren't rules of the grammar */
+				continue
+			}
+			_rules[i] = func() bool {
+				r, key := &p.profile[i], parsedKey{i, int(position)}
+				r.Calls++
+				/* the memoized results don't count ruleUnknown */
+				if _, ok := memoization.get(memoKey[U]{U(i - 1), position}); ok {
+					r.MemoHits++
+				} else if parsed[key] {
+					r.Reparses++
+				}
+				parsed[key] = true
+				start := time.Now()
+				matched := rule()
+				r.Time += time.Since(start)
+				if matched {
+					r.Matches++
+				} else {
+					r.Failures++
+				}
+				return matched
+			}
+		}
+	}
+
+	// limitDepth wraps the rules to keep track of how deeply they are
+	// nested, if there is a limit.
+	limitDepth := func() {
+		if p.maxDepth == 0 {
+			return
+		}
+		depth := 0
+		for i, rule := range _rules {
+			if rule == nil {
+				continue
+			}
+			_rules[i] = func() bool {
+				if depth == p.maxDepth {
+					if stopped == nil {
+						stopped = p.newDepthError(pegRule(i), position)
+					}
+					return false
+				}
+				depth++
+				matched := rule()
+				depth--
+				return matched
+			}
+		}
 	}
 
 	add := func(rule pegRule, begin U) {
@@ -661,23 +1965,58 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 		}
 	}
 
-	memoize := func(rule U, begin U, tokenIndexStart U, matched bool) {
+	expect := func(what string) {
+		reach = max(reach, position+1)
+		if silent > 0 || position < farthest {
+			return
+		}
+		if position > farthest {
+			farthest, expected = position, expected[:0]
+		}
+		expected = append(expected, what)
+	}
+
+	expectMark := func() int {
+		if farthest == position {
+			return len(expected)
+		}
+		return 0
+	}
+
+	expectRule := func(rule pegRule, begin U, mark int) {
+		if silent > 0 || farthest != begin || rule == start {
+			return
+		}
+		expected = append(expected[:mark], rul3s[rule])
+	}
+	_, _, _ = expect, expectMark, expectRule
+
+	memoize := func(rule U, begin U, tokenIndexStart U, outer U, matched bool) {
+		examined := max(reach, position)
+		reach = max(examined, outer)
 		if p.disableMemoize {
 			return
 		}
 		key := memoKey[U]{rule, begin}
 		if !matched {
-			memoization[key] = memo[U]{Matched: false}
+			memoization.set(key, memo[U]{Matched: false, Reach: examined})
 		} else {
-			memoization[key] = memo[U]{
+			memoization.set(key, memo[U]{
 				Matched: true,
-				Partial: slices.Clone(tree.tree[tokenIndexStart:tokenIndex]),
-			}
+				Partial: tree.tree[tokenIndexStart:tokenIndex],
+				Reach:   examined,
+			})
 		}
 	}
+	/* the left recursive rules are memoized as they grow instead */
+	_ = memoize
 
-	memoizedResult := func(m memo[U]) bool {
+	memoizedResult := func(rule pegRule, m memo[U]) bool {
+		reach = max(reach, m.Reach)
 		if !m.Matched {
+			if rule != start {
+				expect(rul3s[rule])
+			}
 			return false
 		}
 		tree.tree = append(tree.tree[:tokenIndex], m.Partial...)
@@ -697,420 +2036,1056 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 		return false
 	}
 
+	matchCaseInsensitive := func(s string) bool {
+		i := position
+		for _, c := range s {
+			if r := buffer[i]; r != c {
+				f := unicode.SimpleFold(c)
+				for f != c && f != r {
+					f = unicode.SimpleFold(f)
+				}
+				if f != r {
+					reach = max(reach, i+1)
+					return false
+				}
+			}
+			i++
+		}
+		position = i
+		return true
+	}
+
 	_rules = [...]func() bool{
 		nil,
 
-		/* 0 Grammar <- <(Header ('p' 'a' 'c' 'k' 'a' 'g' 'e') MustSpacing Identifier Action0 Import* ('t' 'y' 'p' 'e') MustSpacing Identifier Action1 ('P' 'e' 'g') Spacing Action Action2 Definition+ EndOfFile)> */
+		/* 0 Grammar <- <(Header ('p' 'a' 'c' 'k' 'a' 'g' 'e' MustSpacing Identifier Action0 Import* ('t' 'y' 'p' 'e') MustSpacing Identifier Action1 ('P' 'e' 'g') Spacing Action Action2)? Include* Definition+ EndOfFile)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{0, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{0, position}); ok {
+				return memoizedResult(ruleGrammar, memoized)
 			}
 			position0, tokenIndex0 := position, tokenIndex
+			reach0 := reach
+			reach = position
+			mark0 := expectMark()
 			{
 				position1 := position
+				silent++
 				{
 					position2 := position
 				l3:
 					{
 						position4, tokenIndex4 := position, tokenIndex
+						if limited && !step() {
+							goto l4
+						}
 						{
-							position5 := position
+							begin5, mark5 := position, expectMark()
 							{
-								position6, tokenIndex6 := position, tokenIndex
+								position7 := position
 								{
-									position8 := position
-									{
-										position9, tokenIndex9 := position, tokenIndex
-										if buffer[position] != '#' {
-											goto l10
-										}
-										position++
-										goto l9
-									l10:
-										position, tokenIndex = position9, tokenIndex9
-										if buffer[position] != '/' {
-											goto l7
-										}
-										position++
-										if buffer[position] != '/' {
-											goto l7
-										}
-										position++
-									}
-								l9:
+									position8, tokenIndex8 := position, tokenIndex
 									{
-										position11 := position
-									l12:
+										begin10, mark10 := position, expectMark()
 										{
-											position13, tokenIndex13 := position, tokenIndex
+											position12 := position
 											{
-												position14, tokenIndex14 := position, tokenIndex
-												if !_rules[ruleEndOfLine]() {
+												position13, tokenIndex13 := position, tokenIndex
+												if buffer[position] != '#' {
+													reach = max(reach, position+1)
 													goto l14
 												}
+												position++
 												goto l13
 											l14:
-												position, tokenIndex = position14, tokenIndex14
-											}
-											if !matchDot() {
-												goto l13
+												position, tokenIndex = position13, tokenIndex13
+												if buffer[position] != '/' {
+													reach = max(reach, position+1)
+													goto l10
+												}
+												position++
+												if buffer[position] != '/' {
+													reach = max(reach, position+1)
+													goto l10
+												}
+												position++
 											}
-											goto l12
 										l13:
-											position, tokenIndex = position13, tokenIndex13
+											{
+												position15 := position
+											l16:
+												{
+													position17, tokenIndex17 := position, tokenIndex
+													if limited && !step() {
+														goto l17
+													}
+													{
+														position18, tokenIndex18 := position, tokenIndex
+														silent++
+														if !_rules[ruleEndOfLine]() {
+															goto l18
+														}
+														silent--
+														reach = max(reach, position)
+														goto l17
+													l18:
+														silent--
+														position, tokenIndex = position18, tokenIndex18
+													}
+													if !matchDot() {
+														reach = max(reach, position+1)
+														goto l17
+													}
+													goto l16
+												l17:
+													position, tokenIndex = position17, tokenIndex17
+												}
+												add(rulePegText, position15)
+											}
+											{
+												add(ruleAction67, position)
+											}
+											if !_rules[ruleEndOfLine]() {
+												goto l10
+											}
+											add(ruleHeaderComment, position12)
 										}
-										add(rulePegText, position11)
-									}
-									{
-										add(ruleAction51, position)
-									}
-									if !_rules[ruleEndOfLine]() {
-										goto l7
-									}
-									add(ruleHeaderComment, position8)
-								}
-								goto l6
-							l7:
-								position, tokenIndex = position6, tokenIndex6
-								{
-									position16 := position
-									if !_rules[ruleSpace]() {
-										goto l4
+										goto l11
+									l10:
+										expectRule(ruleHeaderComment, begin10, mark10)
+										goto l9
 									}
-								l17:
+								l11:
+									goto l8
+								l9:
+									position, tokenIndex = position8, tokenIndex8
 									{
-										position18, tokenIndex18 := position, tokenIndex
+										position20 := position
 										if !_rules[ruleSpace]() {
-											goto l18
+											goto l5
+										}
+									l21:
+										{
+											position22, tokenIndex22 := position, tokenIndex
+											if limited && !step() {
+												goto l22
+											}
+											if !_rules[ruleSpace]() {
+												goto l22
+											}
+											goto l21
+										l22:
+											position, tokenIndex = position22, tokenIndex22
 										}
-										goto l17
-									l18:
-										position, tokenIndex = position18, tokenIndex18
+										add(rulePegText, position20)
+									}
+									{
+										add(ruleAction66, position)
 									}
-									add(rulePegText, position16)
-								}
-								{
-									add(ruleAction50, position)
 								}
+							l8:
+								add(ruleHeaderSpaceComment, position7)
 							}
-						l6:
-							add(ruleHeaderSpaceComment, position5)
+							goto l6
+						l5:
+							expectRule(ruleHeaderSpaceComment, begin5, mark5)
+							goto l4
 						}
+					l6:
 						goto l3
 					l4:
 						position, tokenIndex = position4, tokenIndex4
 					}
 					add(ruleHeader, position2)
 				}
-				if buffer[position] != 'p' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'a' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'c' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'k' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'a' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'g' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'e' {
-					goto l0
-				}
-				position++
-				if !_rules[ruleMustSpacing]() {
-					goto l0
-				}
-				if !_rules[ruleIdentifier]() {
-					goto l0
-				}
-				{
-					add(ruleAction0, position)
-				}
-			l21:
+				silent--
 				{
-					position22, tokenIndex22 := position, tokenIndex
-					{
-						position23 := position
-						if buffer[position] != 'i' {
-							goto l22
+					position24, tokenIndex24 := position, tokenIndex
+					if buffer[position] != 'p' {
+						if position >= farthest || position >= reach {
+							expect("'p'")
 						}
-						position++
-						if buffer[position] != 'm' {
-							goto l22
+						goto l24
+					}
+					position++
+					if buffer[position] != 'a' {
+						if position >= farthest || position >= reach {
+							expect("'a'")
 						}
-						position++
-						if buffer[position] != 'p' {
-							goto l22
+						goto l24
+					}
+					position++
+					if buffer[position] != 'c' {
+						if position >= farthest || position >= reach {
+							expect("'c'")
 						}
-						position++
-						if buffer[position] != 'o' {
-							goto l22
+						goto l24
+					}
+					position++
+					if buffer[position] != 'k' {
+						if position >= farthest || position >= reach {
+							expect("'k'")
 						}
-						position++
-						if buffer[position] != 'r' {
-							goto l22
+						goto l24
+					}
+					position++
+					if buffer[position] != 'a' {
+						if position >= farthest || position >= reach {
+							expect("'a'")
 						}
-						position++
-						if buffer[position] != 't' {
-							goto l22
+						goto l24
+					}
+					position++
+					if buffer[position] != 'g' {
+						if position >= farthest || position >= reach {
+							expect("'g'")
+						}
+						goto l24
+					}
+					position++
+					if buffer[position] != 'e' {
+						if position >= farthest || position >= reach {
+							expect("'e'")
+						}
+						goto l24
+					}
+					position++
+					if !_rules[ruleMustSpacing]() {
+						goto l24
+					}
+					if !_rules[ruleIdentifier]() {
+						goto l24
+					}
+					{
+						add(ruleAction0, position)
+					}
+				l27:
+					{
+						position28, tokenIndex28 := position, tokenIndex
+						if limited && !step() {
+							goto l28
 						}
-						position++
-						_rules[ruleSpacing]()
 						{
-							position24, tokenIndex24 := position, tokenIndex
+							begin29, mark29 := position, expectMark()
 							{
-								position26 := position
-								if buffer[position] != '(' {
-									goto l25
+								position31 := position
+								if buffer[position] != 'i' {
+									if position >= farthest || position >= reach {
+										expect("'i'")
+									}
+									goto l29
 								}
 								position++
-								_rules[ruleSpacing]()
-							l27:
-								{
-									position28, tokenIndex28 := position, tokenIndex
-									if !_rules[ruleImportName]() {
-										goto l28
+								if buffer[position] != 'm' {
+									if position >= farthest || position >= reach {
+										expect("'m'")
 									}
-									if buffer[position] != '\n' {
-										goto l28
-									}
-									position++
-									_rules[ruleSpacing]()
-									goto l27
-								l28:
-									position, tokenIndex = position28, tokenIndex28
+									goto l29
 								}
-								_rules[ruleSpacing]()
-								if buffer[position] != ')' {
-									goto l25
+								position++
+								if buffer[position] != 'p' {
+									if position >= farthest || position >= reach {
+										expect("'p'")
+									}
+									goto l29
 								}
 								position++
-								add(ruleMultiImport, position26)
-							}
-							goto l24
-						l25:
-							position, tokenIndex = position24, tokenIndex24
-							{
-								position29 := position
-								if !_rules[ruleImportName]() {
-									goto l22
+								if buffer[position] != 'o' {
+									if position >= farthest || position >= reach {
+										expect("'o'")
+									}
+									goto l29
+								}
+								position++
+								if buffer[position] != 'r' {
+									if position >= farthest || position >= reach {
+										expect("'r'")
+									}
+									goto l29
+								}
+								position++
+								if buffer[position] != 't' {
+									if position >= farthest || position >= reach {
+										expect("'t'")
+									}
+									goto l29
+								}
+								position++
+								silent++
+								_rules[ruleSpacing]()
+								silent--
+								{
+									position32, tokenIndex32 := position, tokenIndex
+									{
+										begin34, mark34 := position, expectMark()
+										{
+											position36 := position
+											if buffer[position] != '(' {
+												if position >= farthest || position >= reach {
+													expect("'('")
+												}
+												goto l34
+											}
+											position++
+											silent++
+											_rules[ruleSpacing]()
+											silent--
+										l37:
+											{
+												position38, tokenIndex38 := position, tokenIndex
+												if limited && !step() {
+													goto l38
+												}
+												if !_rules[ruleImportName]() {
+													goto l38
+												}
+												if buffer[position] != '\n' {
+													if position >= farthest || position >= reach {
+														expect("'\\n'")
+													}
+													goto l38
+												}
+												position++
+												silent++
+												_rules[ruleSpacing]()
+												silent--
+												goto l37
+											l38:
+												position, tokenIndex = position38, tokenIndex38
+											}
+											silent++
+											_rules[ruleSpacing]()
+											silent--
+											if buffer[position] != ')' {
+												if position >= farthest || position >= reach {
+													expect("')'")
+												}
+												goto l34
+											}
+											position++
+											add(ruleMultiImport, position36)
+										}
+										goto l35
+									l34:
+										expectRule(ruleMultiImport, begin34, mark34)
+										goto l33
+									}
+								l35:
+									goto l32
+								l33:
+									position, tokenIndex = position32, tokenIndex32
+									{
+										begin39, mark39 := position, expectMark()
+										{
+											position41 := position
+											if !_rules[ruleImportName]() {
+												goto l39
+											}
+											add(ruleSingleImport, position41)
+										}
+										goto l40
+									l39:
+										expectRule(ruleSingleImport, begin39, mark39)
+										goto l29
+									}
+								l40:
 								}
-								add(ruleSingleImport, position29)
+							l32:
+								silent++
+								_rules[ruleSpacing]()
+								silent--
+								add(ruleImport, position31)
 							}
+							goto l30
+						l29:
+							expectRule(ruleImport, begin29, mark29)
+							goto l28
+						}
+					l30:
+						goto l27
+					l28:
+						position, tokenIndex = position28, tokenIndex28
+					}
+					if buffer[position] != 't' {
+						if position >= farthest || position >= reach {
+							expect("'t'")
 						}
-					l24:
-						_rules[ruleSpacing]()
-						add(ruleImport, position23)
+						goto l24
+					}
+					position++
+					if buffer[position] != 'y' {
+						if position >= farthest || position >= reach {
+							expect("'y'")
+						}
+						goto l24
+					}
+					position++
+					if buffer[position] != 'p' {
+						if position >= farthest || position >= reach {
+							expect("'p'")
+						}
+						goto l24
+					}
+					position++
+					if buffer[position] != 'e' {
+						if position >= farthest || position >= reach {
+							expect("'e'")
+						}
+						goto l24
+					}
+					position++
+					if !_rules[ruleMustSpacing]() {
+						goto l24
 					}
-					goto l21
-				l22:
-					position, tokenIndex = position22, tokenIndex22
-				}
-				if buffer[position] != 't' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'y' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'p' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'e' {
-					goto l0
-				}
-				position++
-				if !_rules[ruleMustSpacing]() {
-					goto l0
-				}
-				if !_rules[ruleIdentifier]() {
-					goto l0
-				}
-				{
-					add(ruleAction1, position)
-				}
-				if buffer[position] != 'P' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'e' {
-					goto l0
-				}
-				position++
-				if buffer[position] != 'g' {
-					goto l0
-				}
-				position++
-				_rules[ruleSpacing]()
-				if !_rules[ruleAction]() {
-					goto l0
-				}
-				{
-					add(ruleAction2, position)
-				}
-				{
-					position34 := position
 					if !_rules[ruleIdentifier]() {
-						goto l0
+						goto l24
 					}
 					{
-						add(ruleAction5, position)
+						add(ruleAction1, position)
 					}
-					if !_rules[ruleLeftArrow]() {
-						goto l0
+					if buffer[position] != 'P' {
+						if position >= farthest || position >= reach {
+							expect("'P'")
+						}
+						goto l24
+					}
+					position++
+					if buffer[position] != 'e' {
+						if position >= farthest || position >= reach {
+							expect("'e'")
+						}
+						goto l24
+					}
+					position++
+					if buffer[position] != 'g' {
+						if position >= farthest || position >= reach {
+							expect("'g'")
+						}
+						goto l24
+					}
+					position++
+					silent++
+					_rules[ruleSpacing]()
+					silent--
+					if !_rules[ruleAction]() {
+						goto l24
 					}
-					_rules[ruleExpression]()
 					{
-						add(ruleAction6, position)
+						add(ruleAction2, position)
+					}
+					goto l25
+				l24:
+					position, tokenIndex = position24, tokenIndex24
+				}
+			l25:
+			l44:
+				{
+					position45, tokenIndex45 := position, tokenIndex
+					if limited && !step() {
+						goto l45
 					}
 					{
-						position37, tokenIndex37 := position, tokenIndex
+						begin46, mark46 := position, expectMark()
 						{
-							position38, tokenIndex38 := position, tokenIndex
-							if !_rules[ruleIdentifier]() {
-								goto l39
+							position48 := position
+							if buffer[position] != 'i' {
+								if position >= farthest || position >= reach {
+									expect("'i'")
+								}
+								goto l46
 							}
-							if !_rules[ruleLeftArrow]() {
-								goto l39
+							position++
+							if buffer[position] != 'n' {
+								if position >= farthest || position >= reach {
+									expect("'n'")
+								}
+								goto l46
+							}
+							position++
+							if buffer[position] != 'c' {
+								if position >= farthest || position >= reach {
+									expect("'c'")
+								}
+								goto l46
+							}
+							position++
+							if buffer[position] != 'l' {
+								if position >= farthest || position >= reach {
+									expect("'l'")
+								}
+								goto l46
+							}
+							position++
+							if buffer[position] != 'u' {
+								if position >= farthest || position >= reach {
+									expect("'u'")
+								}
+								goto l46
+							}
+							position++
+							if buffer[position] != 'd' {
+								if position >= farthest || position >= reach {
+									expect("'d'")
+								}
+								goto l46
+							}
+							position++
+							if buffer[position] != 'e' {
+								if position >= farthest || position >= reach {
+									expect("'e'")
+								}
+								goto l46
+							}
+							position++
+							if !_rules[ruleMustSpacing]() {
+								goto l46
 							}
-							goto l38
-						l39:
-							position, tokenIndex = position38, tokenIndex38
 							{
-								position40, tokenIndex40 := position, tokenIndex
+								position49, tokenIndex49 := position, tokenIndex
+								if !_rules[ruleIdentifier]() {
+									goto l49
+								}
+								{
+									add(ruleAction5, position)
+								}
+								goto l50
+							l49:
+								position, tokenIndex = position49, tokenIndex49
+							}
+						l50:
+							if buffer[position] != '"' {
+								if position >= farthest || position >= reach {
+									expect("'\"'")
+								}
+								goto l46
+							}
+							position++
+							{
+								position52 := position
+								{
+									position55, tokenIndex55 := position, tokenIndex
+									if buffer[position] != '"' {
+										reach = max(reach, position+1)
+										goto l55
+									}
+									position++
+									reach = max(reach, position)
+									goto l46
+								l55:
+									position, tokenIndex = position55, tokenIndex55
+								}
 								if !matchDot() {
-									goto l40
+									if position >= farthest || position >= reach {
+										expect("any character")
+									}
+									goto l46
+								}
+							l53:
+								{
+									position54, tokenIndex54 := position, tokenIndex
+									if limited && !step() {
+										goto l54
+									}
+									{
+										position56, tokenIndex56 := position, tokenIndex
+										if buffer[position] != '"' {
+											reach = max(reach, position+1)
+											goto l56
+										}
+										position++
+										reach = max(reach, position)
+										goto l54
+									l56:
+										position, tokenIndex = position56, tokenIndex56
+									}
+									if !matchDot() {
+										if position >= farthest || position >= reach {
+											expect("any character")
+										}
+										goto l54
+									}
+									goto l53
+								l54:
+									position, tokenIndex = position54, tokenIndex54
+								}
+								add(rulePegText, position52)
+							}
+							if buffer[position] != '"' {
+								if position >= farthest || position >= reach {
+									expect("'\"'")
 								}
-								goto l0
-							l40:
-								position, tokenIndex = position40, tokenIndex40
+								goto l46
+							}
+							position++
+							silent++
+							_rules[ruleSpacing]()
+							silent--
+							{
+								add(ruleAction6, position)
 							}
+							add(ruleInclude, position48)
 						}
-					l38:
-						position, tokenIndex = position37, tokenIndex37
-					}
-					add(ruleDefinition, position34)
+						goto l47
+					l46:
+						expectRule(ruleInclude, begin46, mark46)
+						goto l45
+					}
+				l47:
+					goto l44
+				l45:
+					position, tokenIndex = position45, tokenIndex45
 				}
-			l32:
 				{
-					position33, tokenIndex33 := position, tokenIndex
+					begin60, mark60 := position, expectMark()
 					{
-						position41 := position
-						if !_rules[ruleIdentifier]() {
-							goto l33
+						position62 := position
+					l63:
+						{
+							position64, tokenIndex64 := position, tokenIndex
+							if limited && !step() {
+								goto l64
+							}
+							if !_rules[ruleAnnotation]() {
+								goto l64
+							}
+							{
+								add(ruleAction7, position)
+							}
+							goto l63
+						l64:
+							position, tokenIndex = position64, tokenIndex64
 						}
 						{
-							add(ruleAction5, position)
+							position66, tokenIndex66 := position, tokenIndex
+							{
+								begin68, mark68 := position, expectMark()
+								{
+									position70 := position
+									{
+										position71 := position
+										if !_rules[ruleIdentStart]() {
+											goto l68
+										}
+									l72:
+										{
+											position73, tokenIndex73 := position, tokenIndex
+											if limited && !step() {
+												goto l73
+											}
+											if !_rules[ruleIdentCont]() {
+												goto l73
+											}
+											goto l72
+										l73:
+											position, tokenIndex = position73, tokenIndex73
+										}
+										add(rulePegText, position71)
+									}
+									if !_rules[ruleOpen]() {
+										goto l68
+									}
+									add(ruleTemplate, position70)
+								}
+								goto l69
+							l68:
+								expectRule(ruleTemplate, begin68, mark68)
+								goto l67
+							}
+						l69:
+							{
+								add(ruleAction8, position)
+							}
+							if !_rules[ruleParameter]() {
+								goto l67
+							}
+						l75:
+							{
+								position76, tokenIndex76 := position, tokenIndex
+								if limited && !step() {
+									goto l76
+								}
+								if !_rules[ruleComma]() {
+									goto l76
+								}
+								if !_rules[ruleParameter]() {
+									goto l76
+								}
+								goto l75
+							l76:
+								position, tokenIndex = position76, tokenIndex76
+							}
+							if !_rules[ruleClose]() {
+								goto l67
+							}
+							goto l66
+						l67:
+							position, tokenIndex = position66, tokenIndex66
+							if !_rules[ruleIdentifier]() {
+								goto l60
+							}
+							{
+								add(ruleAction9, position)
+							}
 						}
+					l66:
+						{
+							position78, tokenIndex78 := position, tokenIndex
+							if !_rules[ruleResultType]() {
+								goto l78
+							}
+							{
+								add(ruleAction10, position)
+							}
+							goto l79
+						l78:
+							position, tokenIndex = position78, tokenIndex78
+						}
+					l79:
 						if !_rules[ruleLeftArrow]() {
-							goto l33
+							goto l60
 						}
 						_rules[ruleExpression]()
 						{
-							add(ruleAction6, position)
+							add(ruleAction11, position)
 						}
 						{
-							position44, tokenIndex44 := position, tokenIndex
+							position82, tokenIndex82 := position, tokenIndex
 							{
-								position45, tokenIndex45 := position, tokenIndex
+								position83, tokenIndex83 := position, tokenIndex
+								if !_rules[ruleAnnotation]() {
+									goto l84
+								}
+								goto l83
+							l84:
+								position, tokenIndex = position83, tokenIndex83
 								if !_rules[ruleIdentifier]() {
-									goto l46
+									goto l85
 								}
-								if !_rules[ruleLeftArrow]() {
-									goto l46
+								{
+									position86, tokenIndex86 := position, tokenIndex
+									{
+										position88, tokenIndex88 := position, tokenIndex
+										if !_rules[ruleResultType]() {
+											goto l88
+										}
+										goto l89
+									l88:
+										position, tokenIndex = position88, tokenIndex88
+									}
+								l89:
+									if !_rules[ruleLeftArrow]() {
+										goto l87
+									}
+									goto l86
+								l87:
+									position, tokenIndex = position86, tokenIndex86
+									if !_rules[ruleOpen]() {
+										goto l85
+									}
 								}
-								goto l45
-							l46:
-								position, tokenIndex = position45, tokenIndex45
+							l86:
+								goto l83
+							l85:
+								position, tokenIndex = position83, tokenIndex83
 								{
-									position47, tokenIndex47 := position, tokenIndex
+									position90, tokenIndex90 := position, tokenIndex
 									if !matchDot() {
-										goto l47
+										reach = max(reach, position+1)
+										goto l90
 									}
-									goto l33
-								l47:
-									position, tokenIndex = position47, tokenIndex47
+									reach = max(reach, position)
+									position, tokenIndex = position90, tokenIndex90
+									if position >= farthest || position >= reach {
+										expect("end of input")
+									}
+									goto l60
+								l90:
+									position, tokenIndex = position90, tokenIndex90
 								}
 							}
-						l45:
-							position, tokenIndex = position44, tokenIndex44
+						l83:
+							reach = max(reach, position)
+							position, tokenIndex = position82, tokenIndex82
 						}
-						add(ruleDefinition, position41)
+						add(ruleDefinition, position62)
 					}
-					goto l32
-				l33:
-					position, tokenIndex = position33, tokenIndex33
+					goto l61
+				l60:
+					expectRule(ruleDefinition, begin60, mark60)
+					goto l0
 				}
+			l61:
+			l58:
 				{
-					position48 := position
+					position59, tokenIndex59 := position, tokenIndex
+					if limited && !step() {
+						goto l59
+					}
 					{
-						position49, tokenIndex49 := position, tokenIndex
-						if !matchDot() {
-							goto l49
+						begin91, mark91 := position, expectMark()
+						{
+							position93 := position
+						l94:
+							{
+								position95, tokenIndex95 := position, tokenIndex
+								if limited && !step() {
+									goto l95
+								}
+								if !_rules[ruleAnnotation]() {
+									goto l95
+								}
+								{
+									add(ruleAction7, position)
+								}
+								goto l94
+							l95:
+								position, tokenIndex = position95, tokenIndex95
+							}
+							{
+								position97, tokenIndex97 := position, tokenIndex
+								{
+									begin99, mark99 := position, expectMark()
+									{
+										position101 := position
+										{
+											position102 := position
+											if !_rules[ruleIdentStart]() {
+												goto l99
+											}
+										l103:
+											{
+												position104, tokenIndex104 := position, tokenIndex
+												if limited && !step() {
+													goto l104
+												}
+												if !_rules[ruleIdentCont]() {
+													goto l104
+												}
+												goto l103
+											l104:
+												position, tokenIndex = position104, tokenIndex104
+											}
+											add(rulePegText, position102)
+										}
+										if !_rules[ruleOpen]() {
+											goto l99
+										}
+										add(ruleTemplate, position101)
+									}
+									goto l100
+								l99:
+									expectRule(ruleTemplate, begin99, mark99)
+									goto l98
+								}
+							l100:
+								{
+									add(ruleAction8, position)
+								}
+								if !_rules[ruleParameter]() {
+									goto l98
+								}
+							l106:
+								{
+									position107, tokenIndex107 := position, tokenIndex
+									if limited && !step() {
+										goto l107
+									}
+									if !_rules[ruleComma]() {
+										goto l107
+									}
+									if !_rules[ruleParameter]() {
+										goto l107
+									}
+									goto l106
+								l107:
+									position, tokenIndex = position107, tokenIndex107
+								}
+								if !_rules[ruleClose]() {
+									goto l98
+								}
+								goto l97
+							l98:
+								position, tokenIndex = position97, tokenIndex97
+								if !_rules[ruleIdentifier]() {
+									goto l91
+								}
+								{
+									add(ruleAction9, position)
+								}
+							}
+						l97:
+							{
+								position109, tokenIndex109 := position, tokenIndex
+								if !_rules[ruleResultType]() {
+									goto l109
+								}
+								{
+									add(ruleAction10, position)
+								}
+								goto l110
+							l109:
+								position, tokenIndex = position109, tokenIndex109
+							}
+						l110:
+							if !_rules[ruleLeftArrow]() {
+								goto l91
+							}
+							_rules[ruleExpression]()
+							{
+								add(ruleAction11, position)
+							}
+							{
+								position113, tokenIndex113 := position, tokenIndex
+								{
+									position114, tokenIndex114 := position, tokenIndex
+									if !_rules[ruleAnnotation]() {
+										goto l115
+									}
+									goto l114
+								l115:
+									position, tokenIndex = position114, tokenIndex114
+									if !_rules[ruleIdentifier]() {
+										goto l116
+									}
+									{
+										position117, tokenIndex117 := position, tokenIndex
+										{
+											position119, tokenIndex119 := position, tokenIndex
+											if !_rules[ruleResultType]() {
+												goto l119
+											}
+											goto l120
+										l119:
+											position, tokenIndex = position119, tokenIndex119
+										}
+									l120:
+										if !_rules[ruleLeftArrow]() {
+											goto l118
+										}
+										goto l117
+									l118:
+										position, tokenIndex = position117, tokenIndex117
+										if !_rules[ruleOpen]() {
+											goto l116
+										}
+									}
+								l117:
+									goto l114
+								l116:
+									position, tokenIndex = position114, tokenIndex114
+									{
+										position121, tokenIndex121 := position, tokenIndex
+										if !matchDot() {
+											reach = max(reach, position+1)
+											goto l121
+										}
+										reach = max(reach, position)
+										position, tokenIndex = position121, tokenIndex121
+										if position >= farthest || position >= reach {
+											expect("end of input")
+										}
+										goto l91
+									l121:
+										position, tokenIndex = position121, tokenIndex121
+									}
+								}
+							l114:
+								reach = max(reach, position)
+								position, tokenIndex = position113, tokenIndex113
+							}
+							add(ruleDefinition, position93)
+						}
+						goto l92
+					l91:
+						expectRule(ruleDefinition, begin91, mark91)
+						goto l59
+					}
+				l92:
+					goto l58
+				l59:
+					position, tokenIndex = position59, tokenIndex59
+				}
+				{
+					begin122, mark122 := position, expectMark()
+					{
+						position124 := position
+						{
+							position125, tokenIndex125 := position, tokenIndex
+							if !matchDot() {
+								reach = max(reach, position+1)
+								goto l125
+							}
+							reach = max(reach, position)
+							position, tokenIndex = position125, tokenIndex125
+							if position >= farthest || position >= reach {
+								expect("end of input")
+							}
+							goto l122
+						l125:
+							position, tokenIndex = position125, tokenIndex125
 						}
-						goto l0
-					l49:
-						position, tokenIndex = position49, tokenIndex49
+						add(ruleEndOfFile, position124)
 					}
-					add(ruleEndOfFile, position48)
+					goto l123
+				l122:
+					expectRule(ruleEndOfFile, begin122, mark122)
+					goto l0
 				}
+			l123:
 				add(ruleGrammar, position1)
 			}
-			memoize(0, position0, tokenIndex0, true)
+			memoize(0, position0, tokenIndex0, reach0, true)
 			return true
 		l0:
-			memoize(0, position0, tokenIndex0, false)
+			expectRule(ruleGrammar, position0, mark0)
+			memoize(0, position0, tokenIndex0, reach0, false)
 			position, tokenIndex = position0, tokenIndex0
 			return false
 		},
 		/* 1 Import <- <('i' 'm' 'p' 'o' 'r' 't' Spacing (MultiImport / SingleImport) Spacing)> */
 		nil,
-		/* 2 SingleImport <- <ImportName> */
+		/* 2 SingleImport <- <ImportName: ImportName> */
 		nil,
 		/* 3 MultiImport <- <('(' Spacing (ImportName '\n' Spacing)* Spacing ')')> */
 		nil,
 		/* 4 ImportName <- <((Identifier Action3)? '"' <((&('-') '-') | (&('.') '.') | (&('/') '/') | (&('_') '_') | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))+> '"' Action4)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{4, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{4, position}); ok {
+				return memoizedResult(ruleImportName, memoized)
 			}
-			position53, tokenIndex53 := position, tokenIndex
+			position129, tokenIndex129 := position, tokenIndex
+			reach129 := reach
+			reach = position
+			mark129 := expectMark()
 			{
-				position54 := position
+				position130 := position
 				{
-					position55, tokenIndex55 := position, tokenIndex
+					position131, tokenIndex131 := position, tokenIndex
 					if !_rules[ruleIdentifier]() {
-						goto l55
+						goto l131
 					}
 					{
 						add(ruleAction3, position)
 					}
-					goto l56
-				l55:
-					position, tokenIndex = position55, tokenIndex55
+					goto l132
+				l131:
+					position, tokenIndex = position131, tokenIndex131
 				}
-			l56:
+			l132:
 				if buffer[position] != '"' {
-					goto l53
+					if position >= farthest || position >= reach {
+						expect("'\"'")
+					}
+					goto l129
 				}
 				position++
 				{
-					position58 := position
+					position134 := position
 					{
 						switch buffer[position] {
 						case '-':
@@ -1126,16 +3101,40 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 						case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 							position++
 						default:
+							if position >= farthest || position >= reach {
+								expect("'-'")
+							}
+							if position >= farthest || position >= reach {
+								expect("'.'")
+							}
+							if position >= farthest || position >= reach {
+								expect("'/'")
+							}
+							if position >= farthest || position >= reach {
+								expect("'_'")
+							}
+							if position >= farthest || position >= reach {
+								expect("[A-Z]")
+							}
+							if position >= farthest || position >= reach {
+								expect("[0-9]")
+							}
 							if c := buffer[position]; c < 'a' || c > 'z' {
-								goto l53
+								if position >= farthest || position >= reach {
+									expect("[a-z]")
+								}
+								goto l129
 							}
 							position++
 						}
 					}
 
-				l59:
+				l135:
 					{
-						position60, tokenIndex60 := position, tokenIndex
+						position136, tokenIndex136 := position, tokenIndex
+						if limited && !step() {
+							goto l136
+						}
 						{
 							switch buffer[position] {
 							case '-':
@@ -1151,1251 +3150,2083 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 							case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 								position++
 							default:
+								if position >= farthest || position >= reach {
+									expect("'-'")
+								}
+								if position >= farthest || position >= reach {
+									expect("'.'")
+								}
+								if position >= farthest || position >= reach {
+									expect("'/'")
+								}
+								if position >= farthest || position >= reach {
+									expect("'_'")
+								}
+								if position >= farthest || position >= reach {
+									expect("[A-Z]")
+								}
+								if position >= farthest || position >= reach {
+									expect("[0-9]")
+								}
 								if c := buffer[position]; c < 'a' || c > 'z' {
-									goto l60
+									if position >= farthest || position >= reach {
+										expect("[a-z]")
+									}
+									goto l136
 								}
 								position++
 							}
 						}
 
-						goto l59
-					l60:
-						position, tokenIndex = position60, tokenIndex60
+						goto l135
+					l136:
+						position, tokenIndex = position136, tokenIndex136
 					}
-					add(rulePegText, position58)
+					add(rulePegText, position134)
 				}
 				if buffer[position] != '"' {
-					goto l53
+					if position >= farthest || position >= reach {
+						expect("'\"'")
+					}
+					goto l129
 				}
 				position++
 				{
 					add(ruleAction4, position)
 				}
-				add(ruleImportName, position54)
+				add(ruleImportName, position130)
 			}
-			memoize(4, position53, tokenIndex53, true)
+			memoize(4, position129, tokenIndex129, reach129, true)
 			return true
-		l53:
-			memoize(4, position53, tokenIndex53, false)
-			position, tokenIndex = position53, tokenIndex53
+		l129:
+			expectRule(ruleImportName, position129, mark129)
+			memoize(4, position129, tokenIndex129, reach129, false)
+			position, tokenIndex = position129, tokenIndex129
 			return false
 		},
-		/* 5 Definition <- <(Identifier Action5 LeftArrow Expression Action6 &((Identifier LeftArrow) / !.))> */
+		/* 5 Include <- <('i' 'n' 'c' 'l' 'u' 'd' 'e' MustSpacing (Identifier Action5)? '"' <(!'"' .)+> '"' Spacing Action6)> */
+		nil,
+		/* 6 Definition <- <((Annotation Action7)* ((Template Action8 Parameter (Comma Parameter)* Close) / (Identifier Action9)) (ResultType Action10)? LeftArrow Expression Action11 &(Annotation / (Identifier ((ResultType? LeftArrow) / Open)) / !.))> */
 		nil,
-		/* 6 Expression <- <((Sequence (Slash Sequence Action7)* (Slash Action8)?) / Action9)> */
+		/* 7 Parameter <- <(Identifier Action12)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{6, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position65, tokenIndex65 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{7, position}); ok {
+				return memoizedResult(ruleParameter, memoized)
+			}
+			position142, tokenIndex142 := position, tokenIndex
+			reach142 := reach
+			reach = position
+			mark142 := expectMark()
 			{
-				position66 := position
+				position143 := position
+				if !_rules[ruleIdentifier]() {
+					goto l142
+				}
 				{
-					position67, tokenIndex67 := position, tokenIndex
+					add(ruleAction12, position)
+				}
+				add(ruleParameter, position143)
+			}
+			memoize(7, position142, tokenIndex142, reach142, true)
+			return true
+		l142:
+			expectRule(ruleParameter, position142, mark142)
+			memoize(7, position142, tokenIndex142, reach142, false)
+			position, tokenIndex = position142, tokenIndex142
+			return false
+		},
+		/* 8 Expression <- <((Sequence (Slash Sequence Action13)* (Slash Action14)?) / Action15)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{8, position}); ok {
+				return memoizedResult(ruleExpression, memoized)
+			}
+			position145, tokenIndex145 := position, tokenIndex
+			reach145 := reach
+			reach = position
+			{
+				position146 := position
+				{
+					position147, tokenIndex147 := position, tokenIndex
 					if !_rules[ruleSequence]() {
-						goto l68
+						goto l148
 					}
-				l69:
+				l149:
 					{
-						position70, tokenIndex70 := position, tokenIndex
+						position150, tokenIndex150 := position, tokenIndex
+						if limited && !step() {
+							goto l150
+						}
 						if !_rules[ruleSlash]() {
-							goto l70
+							goto l150
 						}
 						if !_rules[ruleSequence]() {
-							goto l70
+							goto l150
 						}
 						{
-							add(ruleAction7, position)
+							add(ruleAction13, position)
 						}
-						goto l69
-					l70:
-						position, tokenIndex = position70, tokenIndex70
+						goto l149
+					l150:
+						position, tokenIndex = position150, tokenIndex150
 					}
 					{
-						position72, tokenIndex72 := position, tokenIndex
+						position152, tokenIndex152 := position, tokenIndex
 						if !_rules[ruleSlash]() {
-							goto l72
+							goto l152
 						}
 						{
-							add(ruleAction8, position)
+							add(ruleAction14, position)
 						}
-						goto l73
-					l72:
-						position, tokenIndex = position72, tokenIndex72
+						goto l153
+					l152:
+						position, tokenIndex = position152, tokenIndex152
 					}
-				l73:
-					goto l67
-				l68:
-					position, tokenIndex = position67, tokenIndex67
+				l153:
+					goto l147
+				l148:
+					position, tokenIndex = position147, tokenIndex147
 					{
-						add(ruleAction9, position)
+						add(ruleAction15, position)
 					}
 				}
-			l67:
-				add(ruleExpression, position66)
+			l147:
+				add(ruleExpression, position146)
 			}
-			memoize(6, position65, tokenIndex65, true)
+			memoize(8, position145, tokenIndex145, reach145, true)
 			return true
 		},
-		/* 7 Sequence <- <(Prefix (Prefix Action10)*)> */
+		/* 9 Sequence <- <(Prefix (Prefix Action16)*)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{7, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{9, position}); ok {
+				return memoizedResult(ruleSequence, memoized)
 			}
-			position76, tokenIndex76 := position, tokenIndex
+			position156, tokenIndex156 := position, tokenIndex
+			reach156 := reach
+			reach = position
+			mark156 := expectMark()
 			{
-				position77 := position
+				position157 := position
 				if !_rules[rulePrefix]() {
-					goto l76
+					goto l156
 				}
-			l78:
+			l158:
 				{
-					position79, tokenIndex79 := position, tokenIndex
+					position159, tokenIndex159 := position, tokenIndex
+					if limited && !step() {
+						goto l159
+					}
 					if !_rules[rulePrefix]() {
-						goto l79
+						goto l159
 					}
 					{
-						add(ruleAction10, position)
+						add(ruleAction16, position)
 					}
-					goto l78
-				l79:
-					position, tokenIndex = position79, tokenIndex79
+					goto l158
+				l159:
+					position, tokenIndex = position159, tokenIndex159
 				}
-				add(ruleSequence, position77)
+				add(ruleSequence, position157)
 			}
-			memoize(7, position76, tokenIndex76, true)
+			memoize(9, position156, tokenIndex156, reach156, true)
 			return true
-		l76:
-			memoize(7, position76, tokenIndex76, false)
-			position, tokenIndex = position76, tokenIndex76
+		l156:
+			expectRule(ruleSequence, position156, mark156)
+			memoize(9, position156, tokenIndex156, reach156, false)
+			position, tokenIndex = position156, tokenIndex156
 			return false
 		},
-		/* 8 Prefix <- <((And Action Action11) / (Not Action Action12) / ((&('!') (Not Suffix Action14)) | (&('&') (And Suffix Action13)) | (&('"' | '\'' | '(' | '.' | '<' | 'A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z' | '[' | '_' | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z' | '{') Suffix)))> */
+		/* 10 Prefix <- <((And Action Action17) / (Not Action Action18) / (Identifier Action22 Colon Suffix Action23) / ((&('~') (Tilde Action21)) | (&('!') (Not Suffix Action20)) | (&('&') (And Suffix Action19)) | (&('"' | '\'' | '(' | '.' | '<' | 'A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z' | '[' | '_' | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z' | '{') Suffix)))> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{8, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position81, tokenIndex81 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{10, position}); ok {
+				return memoizedResult(rulePrefix, memoized)
+			}
+			position161, tokenIndex161 := position, tokenIndex
+			reach161 := reach
+			reach = position
+			mark161 := expectMark()
 			{
-				position82 := position
+				position162 := position
 				{
-					position83, tokenIndex83 := position, tokenIndex
+					position163, tokenIndex163 := position, tokenIndex
 					if !_rules[ruleAnd]() {
-						goto l84
+						goto l164
 					}
 					if !_rules[ruleAction]() {
-						goto l84
+						goto l164
 					}
 					{
-						add(ruleAction11, position)
+						add(ruleAction17, position)
 					}
-					goto l83
-				l84:
-					position, tokenIndex = position83, tokenIndex83
+					goto l163
+				l164:
+					position, tokenIndex = position163, tokenIndex163
 					if !_rules[ruleNot]() {
-						goto l86
+						goto l166
 					}
 					if !_rules[ruleAction]() {
-						goto l86
+						goto l166
+					}
+					{
+						add(ruleAction18, position)
+					}
+					goto l163
+				l166:
+					position, tokenIndex = position163, tokenIndex163
+					if !_rules[ruleIdentifier]() {
+						goto l168
 					}
 					{
-						add(ruleAction12, position)
+						add(ruleAction22, position)
 					}
-					goto l83
-				l86:
-					position, tokenIndex = position83, tokenIndex83
+					if !_rules[ruleColon]() {
+						goto l168
+					}
+					if !_rules[ruleSuffix]() {
+						goto l168
+					}
+					{
+						add(ruleAction23, position)
+					}
+					goto l163
+				l168:
+					position, tokenIndex = position163, tokenIndex163
 					{
 						switch buffer[position] {
+						case '~':
+							{
+								begin172, mark172 := position, expectMark()
+								{
+									position174 := position
+									position++
+									silent++
+									_rules[ruleSpacing]()
+									silent--
+									add(ruleTilde, position174)
+								}
+								_, _ = begin172, mark172
+							}
+							{
+								add(ruleAction21, position)
+							}
 						case '!':
 							if !_rules[ruleNot]() {
-								goto l81
+								goto l161
 							}
 							if !_rules[ruleSuffix]() {
-								goto l81
+								goto l161
 							}
 							{
-								add(ruleAction14, position)
+								add(ruleAction20, position)
 							}
 						case '&':
 							if !_rules[ruleAnd]() {
-								goto l81
+								goto l161
 							}
 							if !_rules[ruleSuffix]() {
-								goto l81
+								goto l161
 							}
 							{
-								add(ruleAction13, position)
+								add(ruleAction19, position)
 							}
 						default:
+							if position >= farthest || position >= reach {
+								expect("Tilde")
+							}
+							if position >= farthest || position >= reach {
+								expect("Not")
+							}
+							if position >= farthest || position >= reach {
+								expect("And")
+							}
 							if !_rules[ruleSuffix]() {
-								goto l81
+								goto l161
 							}
 						}
 					}
 
 				}
-			l83:
-				add(rulePrefix, position82)
+			l163:
+				add(rulePrefix, position162)
 			}
-			memoize(8, position81, tokenIndex81, true)
+			memoize(10, position161, tokenIndex161, reach161, true)
 			return true
-		l81:
-			memoize(8, position81, tokenIndex81, false)
-			position, tokenIndex = position81, tokenIndex81
+		l161:
+			expectRule(rulePrefix, position161, mark161)
+			memoize(10, position161, tokenIndex161, reach161, false)
+			position, tokenIndex = position161, tokenIndex161
 			return false
 		},
-		/* 9 Suffix <- <(Primary ((&('+') (Plus Action17)) | (&('*') (Star Action16)) | (&('?') (Question Action15)))?)> */
+		/* 11 Suffix <- <(Primary ((&('+') (Plus Action26)) | (&('*') (Star Action25)) | (&('?') (Question Action24)))? (Caret Identifier Action27)?)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{9, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{11, position}); ok {
+				return memoizedResult(ruleSuffix, memoized)
 			}
-			position91, tokenIndex91 := position, tokenIndex
+			position178, tokenIndex178 := position, tokenIndex
+			reach178 := reach
+			reach = position
+			mark178 := expectMark()
 			{
-				position92 := position
+				position179 := position
 				{
-					position93 := position
+					begin180, mark180 := position, expectMark()
 					{
-						switch buffer[position] {
-						case '<':
+						position182 := position
+						{
+							position183, tokenIndex183 := position, tokenIndex
+							if !_rules[ruleCall]() {
+								goto l184
+							}
 							{
-								position95 := position
-								position++
-								_rules[ruleSpacing]()
-								add(ruleBegin, position95)
+								add(ruleAction28, position)
 							}
-							_rules[ruleExpression]()
+							_rules[ruleArgument]()
+						l186:
 							{
-								position96 := position
-								if buffer[position] != '>' {
-									goto l91
+								position187, tokenIndex187 := position, tokenIndex
+								if limited && !step() {
+									goto l187
 								}
-								position++
-								_rules[ruleSpacing]()
-								add(ruleEnd, position96)
+								if !_rules[ruleComma]() {
+									goto l187
+								}
+								_rules[ruleArgument]()
+								goto l186
+							l187:
+								position, tokenIndex = position187, tokenIndex187
+							}
+							if !_rules[ruleClose]() {
+								goto l184
 							}
 							{
-								add(ruleAction21, position)
+								position188, tokenIndex188 := position, tokenIndex
+								silent++
+								{
+									position189, tokenIndex189 := position, tokenIndex
+									if !_rules[ruleResultType]() {
+										goto l189
+									}
+									goto l190
+								l189:
+									position, tokenIndex = position189, tokenIndex189
+								}
+							l190:
+								if !_rules[ruleLeftArrow]() {
+									goto l188
+								}
+								silent--
+								reach = max(reach, position)
+								goto l184
+							l188:
+								silent--
+								position, tokenIndex = position188, tokenIndex188
 							}
-						case '{':
-							if !_rules[ruleAction]() {
-								goto l91
+							goto l183
+						l184:
+							position, tokenIndex = position183, tokenIndex183
+							if !_rules[ruleBegin]() {
+								goto l191
 							}
-							{
-								add(ruleAction20, position)
+							if !_rules[ruleIdentifier]() {
+								goto l191
 							}
-						case '.':
 							{
-								position99 := position
-								position++
-								_rules[ruleSpacing]()
-								add(ruleDot, position99)
+								add(ruleAction32, position)
+							}
+							if !_rules[ruleColon]() {
+								goto l191
+							}
+							_rules[ruleExpression]()
+							if !_rules[ruleEnd]() {
+								goto l191
 							}
 							{
-								add(ruleAction19, position)
+								add(ruleAction33, position)
 							}
-						case '[':
+							goto l183
+						l191:
+							position, tokenIndex = position183, tokenIndex183
 							{
-								position101 := position
-								{
-									position102, tokenIndex102 := position, tokenIndex
-									position++
-									if buffer[position] != '[' {
-										goto l103
+								switch buffer[position] {
+								case '<':
+									if !_rules[ruleBegin]() {
+										goto l180
 									}
-									position++
-									{
-										position104, tokenIndex104 := position, tokenIndex
-										{
-											position106, tokenIndex106 := position, tokenIndex
-											if buffer[position] != '^' {
-												goto l107
-											}
-											position++
-											if !_rules[ruleDoubleRanges]() {
-												goto l107
-											}
-											{
-												add(ruleAction24, position)
-											}
-											goto l106
-										l107:
-											position, tokenIndex = position106, tokenIndex106
-											if !_rules[ruleDoubleRanges]() {
-												goto l104
-											}
-										}
-									l106:
-										goto l105
-									l104:
-										position, tokenIndex = position104, tokenIndex104
+									_rules[ruleExpression]()
+									if !_rules[ruleEnd]() {
+										goto l180
 									}
-								l105:
-									if buffer[position] != ']' {
-										goto l103
+									{
+										add(ruleAction34, position)
 									}
-									position++
-									if buffer[position] != ']' {
-										goto l103
+								case '{':
+									if !_rules[ruleAction]() {
+										goto l180
 									}
-									position++
-									goto l102
-								l103:
-									position, tokenIndex = position102, tokenIndex102
-									if buffer[position] != '[' {
-										goto l91
+									{
+										add(ruleAction31, position)
 									}
-									position++
+								case '.':
 									{
-										position109, tokenIndex109 := position, tokenIndex
+										begin197, mark197 := position, expectMark()
 										{
-											position111, tokenIndex111 := position, tokenIndex
-											if buffer[position] != '^' {
-												goto l112
-											}
+											position199 := position
 											position++
-											if !_rules[ruleRanges]() {
-												goto l112
-											}
-											{
-												add(ruleAction25, position)
-											}
-											goto l111
-										l112:
-											position, tokenIndex = position111, tokenIndex111
-											if !_rules[ruleRanges]() {
-												goto l109
-											}
+											silent++
+											_rules[ruleSpacing]()
+											silent--
+											add(ruleDot, position199)
 										}
-									l111:
-										goto l110
-									l109:
-										position, tokenIndex = position109, tokenIndex109
+										_, _ = begin197, mark197
 									}
-								l110:
-									if buffer[position] != ']' {
-										goto l91
-									}
-									position++
-								}
-							l102:
-								_rules[ruleSpacing]()
-								add(ruleClass, position101)
-							}
-						case '"', '\'':
-							{
-								position114 := position
-								{
-									position115, tokenIndex115 := position, tokenIndex
-									if buffer[position] != '\'' {
-										goto l116
+									{
+										add(ruleAction30, position)
 									}
-									position++
+								case '[':
 									{
-										position117, tokenIndex117 := position, tokenIndex
+										begin201, mark201 := position, expectMark()
 										{
-											position119, tokenIndex119 := position, tokenIndex
-											if buffer[position] != '\'' {
-												goto l119
+											position203 := position
+											{
+												position204, tokenIndex204 := position, tokenIndex
+												position++
+												if buffer[position] != '[' {
+													if position >= farthest || position >= reach {
+														expect("'['")
+													}
+													goto l205
+												}
+												position++
+												{
+													position206, tokenIndex206 := position, tokenIndex
+													{
+														position208, tokenIndex208 := position, tokenIndex
+														if buffer[position] != '^' {
+															if position >= farthest || position >= reach {
+																expect("'^'")
+															}
+															goto l209
+														}
+														position++
+														if !_rules[ruleDoubleRanges]() {
+															goto l209
+														}
+														{
+															add(ruleAction39, position)
+														}
+														goto l208
+													l209:
+														position, tokenIndex = position208, tokenIndex208
+														if !_rules[ruleDoubleRanges]() {
+															goto l206
+														}
+													}
+												l208:
+													goto l207
+												l206:
+													position, tokenIndex = position206, tokenIndex206
+												}
+											l207:
+												if buffer[position] != ']' {
+													if position >= farthest || position >= reach {
+														expect("']'")
+													}
+													goto l205
+												}
+												position++
+												if buffer[position] != ']' {
+													if position >= farthest || position >= reach {
+														expect("']'")
+													}
+													goto l205
+												}
+												position++
+												goto l204
+											l205:
+												position, tokenIndex = position204, tokenIndex204
+												if buffer[position] != '[' {
+													if position >= farthest || position >= reach {
+														expect("'['")
+													}
+													goto l201
+												}
+												position++
+												{
+													position211, tokenIndex211 := position, tokenIndex
+													{
+														position213, tokenIndex213 := position, tokenIndex
+														if buffer[position] != '^' {
+															if position >= farthest || position >= reach {
+																expect("'^'")
+															}
+															goto l214
+														}
+														position++
+														if !_rules[ruleRanges]() {
+															goto l214
+														}
+														{
+															add(ruleAction40, position)
+														}
+														goto l213
+													l214:
+														position, tokenIndex = position213, tokenIndex213
+														if !_rules[ruleRanges]() {
+															goto l211
+														}
+													}
+												l213:
+													goto l212
+												l211:
+													position, tokenIndex = position211, tokenIndex211
+												}
+											l212:
+												if buffer[position] != ']' {
+													if position >= farthest || position >= reach {
+														expect("']'")
+													}
+													goto l201
+												}
+												position++
 											}
-											position++
-											goto l117
-										l119:
-											position, tokenIndex = position119, tokenIndex119
-										}
-										if !_rules[ruleChar]() {
-											goto l117
+										l204:
+											silent++
+											_rules[ruleSpacing]()
+											silent--
+											add(ruleClass, position203)
 										}
-										goto l118
-									l117:
-										position, tokenIndex = position117, tokenIndex117
+										goto l202
+									l201:
+										expectRule(ruleClass, begin201, mark201)
+										goto l180
 									}
-								l118:
-								l120:
+								l202:
+									break
+								case '"', '\'':
 									{
-										position121, tokenIndex121 := position, tokenIndex
+										begin216, mark216 := position, expectMark()
 										{
-											position122, tokenIndex122 := position, tokenIndex
-											if buffer[position] != '\'' {
-												goto l122
+											position218 := position
+											{
+												position219, tokenIndex219 := position, tokenIndex
+												if buffer[position] != '\'' {
+													if position >= farthest || position >= reach {
+														expect("'\\''")
+													}
+													goto l220
+												}
+												position++
+												{
+													position221, tokenIndex221 := position, tokenIndex
+													{
+														position223, tokenIndex223 := position, tokenIndex
+														if buffer[position] != '\'' {
+															reach = max(reach, position+1)
+															goto l223
+														}
+														position++
+														reach = max(reach, position)
+														goto l221
+													l223:
+														position, tokenIndex = position223, tokenIndex223
+													}
+													if !_rules[ruleChar]() {
+														goto l221
+													}
+													goto l222
+												l221:
+													position, tokenIndex = position221, tokenIndex221
+												}
+											l222:
+											l224:
+												{
+													position225, tokenIndex225 := position, tokenIndex
+													if limited && !step() {
+														goto l225
+													}
+													{
+														position226, tokenIndex226 := position, tokenIndex
+														if buffer[position] != '\'' {
+															reach = max(reach, position+1)
+															goto l226
+														}
+														position++
+														reach = max(reach, position)
+														goto l225
+													l226:
+														position, tokenIndex = position226, tokenIndex226
+													}
+													if !_rules[ruleChar]() {
+														goto l225
+													}
+													{
+														add(ruleAction36, position)
+													}
+													goto l224
+												l225:
+													position, tokenIndex = position225, tokenIndex225
+												}
+												if buffer[position] != '\'' {
+													if position >= farthest || position >= reach {
+														expect("'\\''")
+													}
+													goto l220
+												}
+												position++
+												silent++
+												_rules[ruleSpacing]()
+												silent--
+												goto l219
+											l220:
+												position, tokenIndex = position219, tokenIndex219
+												if buffer[position] != '"' {
+													if position >= farthest || position >= reach {
+														expect("'\"'")
+													}
+													goto l216
+												}
+												position++
+												{
+													position228, tokenIndex228 := position, tokenIndex
+													{
+														position230, tokenIndex230 := position, tokenIndex
+														if buffer[position] != '"' {
+															reach = max(reach, position+1)
+															goto l230
+														}
+														position++
+														reach = max(reach, position)
+														goto l228
+													l230:
+														position, tokenIndex = position230, tokenIndex230
+													}
+													if !_rules[ruleChar]() {
+														goto l228
+													}
+												l231:
+													{
+														position232, tokenIndex232 := position, tokenIndex
+														if limited && !step() {
+															goto l232
+														}
+														{
+															position233, tokenIndex233 := position, tokenIndex
+															if buffer[position] != '"' {
+																reach = max(reach, position+1)
+																goto l233
+															}
+															position++
+															reach = max(reach, position)
+															goto l232
+														l233:
+															position, tokenIndex = position233, tokenIndex233
+														}
+														if !_rules[ruleChar]() {
+															goto l232
+														}
+														{
+															add(ruleAction37, position)
+														}
+														goto l231
+													l232:
+														position, tokenIndex = position232, tokenIndex232
+													}
+													{
+														add(ruleAction38, position)
+													}
+													goto l229
+												l228:
+													position, tokenIndex = position228, tokenIndex228
+												}
+											l229:
+												if buffer[position] != '"' {
+													if position >= farthest || position >= reach {
+														expect("'\"'")
+													}
+													goto l216
+												}
+												position++
+												silent++
+												_rules[ruleSpacing]()
+												silent--
 											}
-											position++
-											goto l121
-										l122:
-											position, tokenIndex = position122, tokenIndex122
-										}
-										if !_rules[ruleChar]() {
-											goto l121
+										l219:
+											add(ruleLiteral, position218)
 										}
-										{
-											add(ruleAction22, position)
-										}
-										goto l120
-									l121:
-										position, tokenIndex = position121, tokenIndex121
+										goto l217
+									l216:
+										expectRule(ruleLiteral, begin216, mark216)
+										goto l180
 									}
-									if buffer[position] != '\'' {
-										goto l116
+								l217:
+									break
+								case '(':
+									if !_rules[ruleOpen]() {
+										goto l180
 									}
-									position++
-									_rules[ruleSpacing]()
-									goto l115
-								l116:
-									position, tokenIndex = position115, tokenIndex115
-									if buffer[position] != '"' {
-										goto l91
+									_rules[ruleExpression]()
+									if !_rules[ruleClose]() {
+										goto l180
+									}
+								default:
+									if position >= farthest || position >= reach {
+										expect("Begin")
+									}
+									if position >= farthest || position >= reach {
+										expect("Action")
+									}
+									if position >= farthest || position >= reach {
+										expect("Dot")
+									}
+									if position >= farthest || position >= reach {
+										expect("Class")
+									}
+									if position >= farthest || position >= reach {
+										expect("Literal")
+									}
+									if position >= farthest || position >= reach {
+										expect("Open")
+									}
+									{
+										position236, tokenIndex236 := position, tokenIndex
+										silent++
+										if !_rules[ruleCall]() {
+											goto l236
+										}
+										silent--
+										reach = max(reach, position)
+										goto l180
+									l236:
+										silent--
+										position, tokenIndex = position236, tokenIndex236
 									}
-									position++
 									{
-										position124, tokenIndex124 := position, tokenIndex
+										begin237, mark237 := position, expectMark()
 										{
-											position126, tokenIndex126 := position, tokenIndex
-											if buffer[position] != '"' {
-												goto l126
+											position239 := position
+											{
+												position240 := position
+												if !_rules[ruleIdentStart]() {
+													goto l237
+												}
+											l241:
+												{
+													position242, tokenIndex242 := position, tokenIndex
+													if limited && !step() {
+														goto l242
+													}
+													if !_rules[ruleIdentCont]() {
+														goto l242
+													}
+													goto l241
+												l242:
+													position, tokenIndex = position242, tokenIndex242
+												}
+											l243:
+												{
+													position244, tokenIndex244 := position, tokenIndex
+													if limited && !step() {
+														goto l244
+													}
+													if buffer[position] != '.' {
+														if position >= farthest || position >= reach {
+															expect("'.'")
+														}
+														goto l244
+													}
+													position++
+													if !_rules[ruleIdentStart]() {
+														goto l244
+													}
+												l245:
+													{
+														position246, tokenIndex246 := position, tokenIndex
+														if limited && !step() {
+															goto l246
+														}
+														if !_rules[ruleIdentCont]() {
+															goto l246
+														}
+														goto l245
+													l246:
+														position, tokenIndex = position246, tokenIndex246
+													}
+													goto l243
+												l244:
+													position, tokenIndex = position244, tokenIndex244
+												}
+												add(rulePegText, position240)
 											}
-											position++
-											goto l124
-										l126:
-											position, tokenIndex = position126, tokenIndex126
+											silent++
+											_rules[ruleSpacing]()
+											silent--
+											add(ruleReference, position239)
 										}
-										if !_rules[ruleDoubleChar]() {
-											goto l124
-										}
-										goto l125
-									l124:
-										position, tokenIndex = position124, tokenIndex124
+										goto l238
+									l237:
+										expectRule(ruleReference, begin237, mark237)
+										goto l180
 									}
-								l125:
-								l127:
+								l238:
 									{
-										position128, tokenIndex128 := position, tokenIndex
+										position247, tokenIndex247 := position, tokenIndex
+										silent++
 										{
-											position129, tokenIndex129 := position, tokenIndex
-											if buffer[position] != '"' {
-												goto l129
+											position248, tokenIndex248 := position, tokenIndex
+											if !_rules[ruleResultType]() {
+												goto l248
 											}
-											position++
-											goto l128
-										l129:
-											position, tokenIndex = position129, tokenIndex129
+											goto l249
+										l248:
+											position, tokenIndex = position248, tokenIndex248
 										}
-										if !_rules[ruleDoubleChar]() {
-											goto l128
+									l249:
+										if !_rules[ruleLeftArrow]() {
+											goto l247
 										}
-										{
-											add(ruleAction23, position)
-										}
-										goto l127
-									l128:
-										position, tokenIndex = position128, tokenIndex128
+										silent--
+										reach = max(reach, position)
+										goto l180
+									l247:
+										silent--
+										position, tokenIndex = position247, tokenIndex247
 									}
-									if buffer[position] != '"' {
-										goto l91
+									{
+										add(ruleAction29, position)
 									}
-									position++
-									_rules[ruleSpacing]()
-								}
-							l115:
-								add(ruleLiteral, position114)
-							}
-						case '(':
-							{
-								position131 := position
-								position++
-								_rules[ruleSpacing]()
-								add(ruleOpen, position131)
-							}
-							_rules[ruleExpression]()
-							{
-								position132 := position
-								if buffer[position] != ')' {
-									goto l91
 								}
-								position++
-								_rules[ruleSpacing]()
-								add(ruleClose, position132)
-							}
-						default:
-							if !_rules[ruleIdentifier]() {
-								goto l91
-							}
-							{
-								position133, tokenIndex133 := position, tokenIndex
-								if !_rules[ruleLeftArrow]() {
-									goto l133
-								}
-								goto l91
-							l133:
-								position, tokenIndex = position133, tokenIndex133
-							}
-							{
-								add(ruleAction18, position)
 							}
+
 						}
+					l183:
+						add(rulePrimary, position182)
 					}
-
-					add(rulePrimary, position93)
+					goto l181
+				l180:
+					expectRule(rulePrimary, begin180, mark180)
+					goto l178
 				}
+			l181:
 				{
-					position135, tokenIndex135 := position, tokenIndex
+					position251, tokenIndex251 := position, tokenIndex
 					{
 						switch buffer[position] {
 						case '+':
 							{
-								position138 := position
-								position++
-								_rules[ruleSpacing]()
-								add(rulePlus, position138)
+								begin254, mark254 := position, expectMark()
+								{
+									position256 := position
+									position++
+									silent++
+									_rules[ruleSpacing]()
+									silent--
+									add(rulePlus, position256)
+								}
+								_, _ = begin254, mark254
 							}
 							{
-								add(ruleAction17, position)
+								add(ruleAction26, position)
 							}
 						case '*':
 							{
-								position140 := position
-								position++
-								_rules[ruleSpacing]()
-								add(ruleStar, position140)
+								begin258, mark258 := position, expectMark()
+								{
+									position260 := position
+									position++
+									silent++
+									_rules[ruleSpacing]()
+									silent--
+									add(ruleStar, position260)
+								}
+								_, _ = begin258, mark258
 							}
 							{
-								add(ruleAction16, position)
+								add(ruleAction25, position)
 							}
 						default:
+							if position >= farthest || position >= reach {
+								expect("Plus")
+							}
+							if position >= farthest || position >= reach {
+								expect("Star")
+							}
 							{
-								position142 := position
-								if buffer[position] != '?' {
-									goto l135
+								begin262, mark262 := position, expectMark()
+								{
+									position264 := position
+									if buffer[position] != '?' {
+										if position >= farthest || position >= reach {
+											expect("'?'")
+										}
+										goto l262
+									}
+									position++
+									silent++
+									_rules[ruleSpacing]()
+									silent--
+									add(ruleQuestion, position264)
 								}
-								position++
-								_rules[ruleSpacing]()
-								add(ruleQuestion, position142)
+								goto l263
+							l262:
+								expectRule(ruleQuestion, begin262, mark262)
+								goto l251
 							}
+						l263:
 							{
-								add(ruleAction15, position)
+								add(ruleAction24, position)
 							}
 						}
 					}
 
-					goto l136
-				l135:
-					position, tokenIndex = position135, tokenIndex135
+					goto l252
+				l251:
+					position, tokenIndex = position251, tokenIndex251
+				}
+			l252:
+				{
+					position266, tokenIndex266 := position, tokenIndex
+					{
+						begin268, mark268 := position, expectMark()
+						{
+							position270 := position
+							if buffer[position] != '^' {
+								if position >= farthest || position >= reach {
+									expect("'^'")
+								}
+								goto l268
+							}
+							position++
+							silent++
+							_rules[ruleSpacing]()
+							silent--
+							add(ruleCaret, position270)
+						}
+						goto l269
+					l268:
+						expectRule(ruleCaret, begin268, mark268)
+						goto l266
+					}
+				l269:
+					if !_rules[ruleIdentifier]() {
+						goto l266
+					}
+					{
+						add(ruleAction27, position)
+					}
+					goto l267
+				l266:
+					position, tokenIndex = position266, tokenIndex266
 				}
-			l136:
-				add(ruleSuffix, position92)
+			l267:
+				add(ruleSuffix, position179)
 			}
-			memoize(9, position91, tokenIndex91, true)
+			memoize(11, position178, tokenIndex178, reach178, true)
 			return true
-		l91:
-			memoize(9, position91, tokenIndex91, false)
-			position, tokenIndex = position91, tokenIndex91
+		l178:
+			expectRule(ruleSuffix, position178, mark178)
+			memoize(11, position178, tokenIndex178, reach178, false)
+			position, tokenIndex = position178, tokenIndex178
 			return false
 		},
-		/* 10 Primary <- <((&('<') (Begin Expression End Action21)) | (&('{') (Action Action20)) | (&('.') (Dot Action19)) | (&('[') Class) | (&('"' | '\'') Literal) | (&('(') (Open Expression Close)) | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z' | '_' | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') (Identifier !LeftArrow Action18)))> */
+		/* 12 Primary <- <((Call Action28 Argument (Comma Argument)* Close !(ResultType? LeftArrow)) / (Begin Identifier Action32 Colon Expression End Action33) / ((&('<') (Begin Expression End Action34)) | (&('{') (Action Action31)) | (&('.') (Dot Action30)) | (&('[') Class) | (&('"' | '\'') Literal) | (&('(') (Open Expression Close)) | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z' | '_' | 'a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') (!Call Reference !(ResultType? LeftArrow) Action29))))> */
 		nil,
-		/* 11 Identifier <- <(<(IdentStart IdentCont*)> Spacing)> */
+		/* 13 Argument <- <(Expression Action35)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{11, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position145, tokenIndex145 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{13, position}); ok {
+				return memoizedResult(ruleArgument, memoized)
+			}
+			position273, tokenIndex273 := position, tokenIndex
+			reach273 := reach
+			reach = position
 			{
-				position146 := position
+				position274 := position
+				_rules[ruleExpression]()
+				{
+					add(ruleAction35, position)
+				}
+				add(ruleArgument, position274)
+			}
+			memoize(13, position273, tokenIndex273, reach273, true)
+			return true
+		},
+		/* 14 Identifier <- <(<(IdentStart IdentCont*)> Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{14, position}); ok {
+				return memoizedResult(ruleIdentifier, memoized)
+			}
+			position276, tokenIndex276 := position, tokenIndex
+			reach276 := reach
+			reach = position
+			mark276 := expectMark()
+			{
+				position277 := position
 				{
-					position147 := position
+					position278 := position
 					if !_rules[ruleIdentStart]() {
-						goto l145
+						goto l276
 					}
-				l148:
+				l279:
+					{
+						position280, tokenIndex280 := position, tokenIndex
+						if limited && !step() {
+							goto l280
+						}
+						if !_rules[ruleIdentCont]() {
+							goto l280
+						}
+						goto l279
+					l280:
+						position, tokenIndex = position280, tokenIndex280
+					}
+					add(rulePegText, position278)
+				}
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleIdentifier, position277)
+			}
+			memoize(14, position276, tokenIndex276, reach276, true)
+			return true
+		l276:
+			expectRule(ruleIdentifier, position276, mark276)
+			memoize(14, position276, tokenIndex276, reach276, false)
+			position, tokenIndex = position276, tokenIndex276
+			return false
+		},
+		/* 15 Template <- <(<(IdentStart IdentCont*)> Open)> */
+		nil,
+		/* 16 Annotation <- <('@' <(IdentStart IdentCont*)> Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{16, position}); ok {
+				return memoizedResult(ruleAnnotation, memoized)
+			}
+			position282, tokenIndex282 := position, tokenIndex
+			reach282 := reach
+			reach = position
+			mark282 := expectMark()
+			{
+				position283 := position
+				if buffer[position] != '@' {
+					if position >= farthest || position >= reach {
+						expect("'@'")
+					}
+					goto l282
+				}
+				position++
+				{
+					position284 := position
+					if !_rules[ruleIdentStart]() {
+						goto l282
+					}
+				l285:
+					{
+						position286, tokenIndex286 := position, tokenIndex
+						if limited && !step() {
+							goto l286
+						}
+						if !_rules[ruleIdentCont]() {
+							goto l286
+						}
+						goto l285
+					l286:
+						position, tokenIndex = position286, tokenIndex286
+					}
+					add(rulePegText, position284)
+				}
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleAnnotation, position283)
+			}
+			memoize(16, position282, tokenIndex282, reach282, true)
+			return true
+		l282:
+			expectRule(ruleAnnotation, position282, mark282)
+			memoize(16, position282, tokenIndex282, reach282, false)
+			position, tokenIndex = position282, tokenIndex282
+			return false
+		},
+		/* 17 Reference <- <(<(IdentStart IdentCont* ('.' IdentStart IdentCont*)*)> Spacing)> */
+		nil,
+		/* 18 Call <- <(<(IdentStart IdentCont* ('.' IdentStart IdentCont*)*)> Open)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{18, position}); ok {
+				return memoizedResult(ruleCall, memoized)
+			}
+			position288, tokenIndex288 := position, tokenIndex
+			reach288 := reach
+			reach = position
+			mark288 := expectMark()
+			{
+				position289 := position
+				{
+					position290 := position
+					if !_rules[ruleIdentStart]() {
+						goto l288
+					}
+				l291:
 					{
-						position149, tokenIndex149 := position, tokenIndex
+						position292, tokenIndex292 := position, tokenIndex
+						if limited && !step() {
+							goto l292
+						}
+						if !_rules[ruleIdentCont]() {
+							goto l292
+						}
+						goto l291
+					l292:
+						position, tokenIndex = position292, tokenIndex292
+					}
+				l293:
+					{
+						position294, tokenIndex294 := position, tokenIndex
+						if limited && !step() {
+							goto l294
+						}
+						if buffer[position] != '.' {
+							if position >= farthest || position >= reach {
+								expect("'.'")
+							}
+							goto l294
+						}
+						position++
+						if !_rules[ruleIdentStart]() {
+							goto l294
+						}
+					l295:
 						{
-							position150 := position
-							{
-								position151, tokenIndex151 := position, tokenIndex
-								if !_rules[ruleIdentStart]() {
-									goto l152
-								}
-								goto l151
-							l152:
-								position, tokenIndex = position151, tokenIndex151
-								if c := buffer[position]; c < '0' || c > '9' {
-									goto l149
-								}
-								position++
+							position296, tokenIndex296 := position, tokenIndex
+							if limited && !step() {
+								goto l296
 							}
-						l151:
-							add(ruleIdentCont, position150)
+							if !_rules[ruleIdentCont]() {
+								goto l296
+							}
+							goto l295
+						l296:
+							position, tokenIndex = position296, tokenIndex296
 						}
-						goto l148
-					l149:
-						position, tokenIndex = position149, tokenIndex149
+						goto l293
+					l294:
+						position, tokenIndex = position294, tokenIndex294
 					}
-					add(rulePegText, position147)
+					add(rulePegText, position290)
 				}
-				_rules[ruleSpacing]()
-				add(ruleIdentifier, position146)
+				if !_rules[ruleOpen]() {
+					goto l288
+				}
+				add(ruleCall, position289)
 			}
-			memoize(11, position145, tokenIndex145, true)
+			memoize(18, position288, tokenIndex288, reach288, true)
 			return true
-		l145:
-			memoize(11, position145, tokenIndex145, false)
-			position, tokenIndex = position145, tokenIndex145
+		l288:
+			expectRule(ruleCall, position288, mark288)
+			memoize(18, position288, tokenIndex288, reach288, false)
+			position, tokenIndex = position288, tokenIndex288
 			return false
 		},
-		/* 12 IdentStart <- <((&('_') '_') | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))> */
+		/* 19 IdentStart <- <((&('_') "_") | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{12, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position153, tokenIndex153 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{19, position}); ok {
+				return memoizedResult(ruleIdentStart, memoized)
+			}
+			position297, tokenIndex297 := position, tokenIndex
+			reach297 := reach
+			reach = position
+			mark297 := expectMark()
 			{
-				position154 := position
+				position298 := position
 				{
 					switch buffer[position] {
 					case '_':
-						position++
+						if !matchCaseInsensitive("_") {
+							if position >= farthest || position >= reach {
+								expect("\"_\"")
+							}
+							goto l297
+						}
 					case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
 						position++
 					default:
+						if position >= farthest || position >= reach {
+							expect("\"_\"")
+						}
+						if position >= farthest || position >= reach {
+							expect("[A-Z]")
+						}
 						if c := buffer[position]; c < 'a' || c > 'z' {
-							goto l153
+							if position >= farthest || position >= reach {
+								expect("[a-z]")
+							}
+							goto l297
 						}
 						position++
 					}
 				}
 
-				add(ruleIdentStart, position154)
+				add(ruleIdentStart, position298)
 			}
-			memoize(12, position153, tokenIndex153, true)
+			memoize(19, position297, tokenIndex297, reach297, true)
 			return true
-		l153:
-			memoize(12, position153, tokenIndex153, false)
-			position, tokenIndex = position153, tokenIndex153
+		l297:
+			expectRule(ruleIdentStart, position297, mark297)
+			memoize(19, position297, tokenIndex297, reach297, false)
+			position, tokenIndex = position297, tokenIndex297
 			return false
 		},
-		/* 13 IdentCont <- <(IdentStart / [0-9])> */
-		nil,
-		/* 14 Literal <- <(('\'' (!'\'' Char)? (!'\'' Char Action22)* '\'' Spacing) / ('"' (!'"' DoubleChar)? (!'"' DoubleChar Action23)* '"' Spacing))> */
+		/* 20 IdentCont <- <(IdentStart / [0-9])> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{20, position}); ok {
+				return memoizedResult(ruleIdentCont, memoized)
+			}
+			position300, tokenIndex300 := position, tokenIndex
+			reach300 := reach
+			reach = position
+			mark300 := expectMark()
+			{
+				position301 := position
+				{
+					position302, tokenIndex302 := position, tokenIndex
+					if !_rules[ruleIdentStart]() {
+						goto l303
+					}
+					goto l302
+				l303:
+					position, tokenIndex = position302, tokenIndex302
+					if c := buffer[position]; c < '0' || c > '9' {
+						if position >= farthest || position >= reach {
+							expect("[0-9]")
+						}
+						goto l300
+					}
+					position++
+				}
+			l302:
+				add(ruleIdentCont, position301)
+			}
+			memoize(20, position300, tokenIndex300, reach300, true)
+			return true
+		l300:
+			expectRule(ruleIdentCont, position300, mark300)
+			memoize(20, position300, tokenIndex300, reach300, false)
+			position, tokenIndex = position300, tokenIndex300
+			return false
+		},
+		/* 21 Literal <- <(('\'' (!'\'' Char)? (!'\'' Char Action36)* '\'' Spacing) / ('"' (!'"' Char (!'"' Char Action37)* Action38)? '"' Spacing))> */
 		nil,
-		/* 15 Class <- <((('[' '[' (('^' DoubleRanges Action24) / DoubleRanges)? (']' ']')) / ('[' (('^' Ranges Action25) / Ranges)? ']')) Spacing)> */
+		/* 22 Class <- <((('[' '[' (('^' DoubleRanges Action39) / DoubleRanges)? (']' ']')) / ('[' (('^' Ranges Action40) / Ranges)? ']')) Spacing)> */
 		nil,
-		/* 16 Ranges <- <(!']' Range (!']' Range Action26)*)> */
+		/* 23 Ranges <- <(!']' Range (!']' Range Action41)*)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{16, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position159, tokenIndex159 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{23, position}); ok {
+				return memoizedResult(ruleRanges, memoized)
+			}
+			position306, tokenIndex306 := position, tokenIndex
+			reach306 := reach
+			reach = position
+			mark306 := expectMark()
 			{
-				position160 := position
+				position307 := position
 				{
-					position161, tokenIndex161 := position, tokenIndex
+					position308, tokenIndex308 := position, tokenIndex
 					if buffer[position] != ']' {
-						goto l161
+						reach = max(reach, position+1)
+						goto l308
 					}
 					position++
-					goto l159
-				l161:
-					position, tokenIndex = position161, tokenIndex161
+					reach = max(reach, position)
+					goto l306
+				l308:
+					position, tokenIndex = position308, tokenIndex308
 				}
 				if !_rules[ruleRange]() {
-					goto l159
+					goto l306
 				}
-			l162:
+			l309:
 				{
-					position163, tokenIndex163 := position, tokenIndex
+					position310, tokenIndex310 := position, tokenIndex
+					if limited && !step() {
+						goto l310
+					}
 					{
-						position164, tokenIndex164 := position, tokenIndex
+						position311, tokenIndex311 := position, tokenIndex
 						if buffer[position] != ']' {
-							goto l164
+							reach = max(reach, position+1)
+							goto l311
 						}
 						position++
-						goto l163
-					l164:
-						position, tokenIndex = position164, tokenIndex164
+						reach = max(reach, position)
+						goto l310
+					l311:
+						position, tokenIndex = position311, tokenIndex311
 					}
 					if !_rules[ruleRange]() {
-						goto l163
+						goto l310
 					}
 					{
-						add(ruleAction26, position)
+						add(ruleAction41, position)
 					}
-					goto l162
-				l163:
-					position, tokenIndex = position163, tokenIndex163
+					goto l309
+				l310:
+					position, tokenIndex = position310, tokenIndex310
 				}
-				add(ruleRanges, position160)
+				add(ruleRanges, position307)
 			}
-			memoize(16, position159, tokenIndex159, true)
+			memoize(23, position306, tokenIndex306, reach306, true)
 			return true
-		l159:
-			memoize(16, position159, tokenIndex159, false)
-			position, tokenIndex = position159, tokenIndex159
+		l306:
+			expectRule(ruleRanges, position306, mark306)
+			memoize(23, position306, tokenIndex306, reach306, false)
+			position, tokenIndex = position306, tokenIndex306
 			return false
 		},
-		/* 17 DoubleRanges <- <(!(']' ']') DoubleRange (!(']' ']') DoubleRange Action27)*)> */
+		/* 24 DoubleRanges <- <(!(']' ']') DoubleRange (!(']' ']') DoubleRange Action42)*)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{17, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{24, position}); ok {
+				return memoizedResult(ruleDoubleRanges, memoized)
 			}
-			position166, tokenIndex166 := position, tokenIndex
+			position313, tokenIndex313 := position, tokenIndex
+			reach313 := reach
+			reach = position
+			mark313 := expectMark()
 			{
-				position167 := position
+				position314 := position
 				{
-					position168, tokenIndex168 := position, tokenIndex
+					position315, tokenIndex315 := position, tokenIndex
 					if buffer[position] != ']' {
-						goto l168
+						reach = max(reach, position+1)
+						goto l315
 					}
 					position++
 					if buffer[position] != ']' {
-						goto l168
+						reach = max(reach, position+1)
+						goto l315
 					}
 					position++
-					goto l166
-				l168:
-					position, tokenIndex = position168, tokenIndex168
+					reach = max(reach, position)
+					goto l313
+				l315:
+					position, tokenIndex = position315, tokenIndex315
 				}
 				if !_rules[ruleDoubleRange]() {
-					goto l166
+					goto l313
 				}
-			l169:
+			l316:
 				{
-					position170, tokenIndex170 := position, tokenIndex
+					position317, tokenIndex317 := position, tokenIndex
+					if limited && !step() {
+						goto l317
+					}
 					{
-						position171, tokenIndex171 := position, tokenIndex
+						position318, tokenIndex318 := position, tokenIndex
 						if buffer[position] != ']' {
-							goto l171
+							reach = max(reach, position+1)
+							goto l318
 						}
 						position++
 						if buffer[position] != ']' {
-							goto l171
+							reach = max(reach, position+1)
+							goto l318
 						}
 						position++
-						goto l170
-					l171:
-						position, tokenIndex = position171, tokenIndex171
+						reach = max(reach, position)
+						goto l317
+					l318:
+						position, tokenIndex = position318, tokenIndex318
 					}
 					if !_rules[ruleDoubleRange]() {
-						goto l170
+						goto l317
 					}
 					{
-						add(ruleAction27, position)
+						add(ruleAction42, position)
 					}
-					goto l169
-				l170:
-					position, tokenIndex = position170, tokenIndex170
+					goto l316
+				l317:
+					position, tokenIndex = position317, tokenIndex317
 				}
-				add(ruleDoubleRanges, position167)
+				add(ruleDoubleRanges, position314)
 			}
-			memoize(17, position166, tokenIndex166, true)
+			memoize(24, position313, tokenIndex313, reach313, true)
 			return true
-		l166:
-			memoize(17, position166, tokenIndex166, false)
-			position, tokenIndex = position166, tokenIndex166
+		l313:
+			expectRule(ruleDoubleRanges, position313, mark313)
+			memoize(24, position313, tokenIndex313, reach313, false)
+			position, tokenIndex = position313, tokenIndex313
 			return false
 		},
-		/* 18 Range <- <((Char '-' Char Action28) / Char)> */
+		/* 25 Range <- <(Property / (Char '-' Char Action43) / Char)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{18, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position173, tokenIndex173 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{25, position}); ok {
+				return memoizedResult(ruleRange, memoized)
+			}
+			position320, tokenIndex320 := position, tokenIndex
+			reach320 := reach
+			reach = position
+			mark320 := expectMark()
 			{
-				position174 := position
+				position321 := position
 				{
-					position175, tokenIndex175 := position, tokenIndex
+					position322, tokenIndex322 := position, tokenIndex
+					if !_rules[ruleProperty]() {
+						goto l323
+					}
+					goto l322
+				l323:
+					position, tokenIndex = position322, tokenIndex322
 					if !_rules[ruleChar]() {
-						goto l176
+						goto l324
 					}
 					if buffer[position] != '-' {
-						goto l176
+						if position >= farthest || position >= reach {
+							expect("'-'")
+						}
+						goto l324
 					}
 					position++
 					if !_rules[ruleChar]() {
-						goto l176
+						goto l324
 					}
 					{
-						add(ruleAction28, position)
+						add(ruleAction43, position)
 					}
-					goto l175
-				l176:
-					position, tokenIndex = position175, tokenIndex175
+					goto l322
+				l324:
+					position, tokenIndex = position322, tokenIndex322
 					if !_rules[ruleChar]() {
-						goto l173
+						goto l320
 					}
 				}
-			l175:
-				add(ruleRange, position174)
+			l322:
+				add(ruleRange, position321)
 			}
-			memoize(18, position173, tokenIndex173, true)
+			memoize(25, position320, tokenIndex320, reach320, true)
 			return true
-		l173:
-			memoize(18, position173, tokenIndex173, false)
-			position, tokenIndex = position173, tokenIndex173
+		l320:
+			expectRule(ruleRange, position320, mark320)
+			memoize(25, position320, tokenIndex320, reach320, false)
+			position, tokenIndex = position320, tokenIndex320
 			return false
 		},
-		/* 19 DoubleRange <- <((Char '-' Char Action29) / DoubleChar)> */
+		/* 26 DoubleRange <- <(Property / (Char '-' Char Action44) / DoubleChar)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{19, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position178, tokenIndex178 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{26, position}); ok {
+				return memoizedResult(ruleDoubleRange, memoized)
+			}
+			position326, tokenIndex326 := position, tokenIndex
+			reach326 := reach
+			reach = position
+			mark326 := expectMark()
 			{
-				position179 := position
+				position327 := position
 				{
-					position180, tokenIndex180 := position, tokenIndex
+					position328, tokenIndex328 := position, tokenIndex
+					if !_rules[ruleProperty]() {
+						goto l329
+					}
+					goto l328
+				l329:
+					position, tokenIndex = position328, tokenIndex328
 					if !_rules[ruleChar]() {
-						goto l181
+						goto l330
 					}
 					if buffer[position] != '-' {
-						goto l181
+						if position >= farthest || position >= reach {
+							expect("'-'")
+						}
+						goto l330
 					}
 					position++
 					if !_rules[ruleChar]() {
-						goto l181
+						goto l330
 					}
 					{
-						add(ruleAction29, position)
+						add(ruleAction44, position)
 					}
-					goto l180
-				l181:
-					position, tokenIndex = position180, tokenIndex180
-					if !_rules[ruleDoubleChar]() {
-						goto l178
+					goto l328
+				l330:
+					position, tokenIndex = position328, tokenIndex328
+					{
+						begin332, mark332 := position, expectMark()
+						{
+							position334 := position
+							{
+								position335, tokenIndex335 := position, tokenIndex
+								if !_rules[ruleEscape]() {
+									goto l336
+								}
+								goto l335
+							l336:
+								position, tokenIndex = position335, tokenIndex335
+								{
+									position337, tokenIndex337 := position, tokenIndex
+									if buffer[position] != '\\' {
+										reach = max(reach, position+1)
+										goto l337
+									}
+									position++
+									reach = max(reach, position)
+									goto l332
+								l337:
+									position, tokenIndex = position337, tokenIndex337
+								}
+								{
+									position338 := position
+									if !matchDot() {
+										if position >= farthest || position >= reach {
+											expect("any character")
+										}
+										goto l332
+									}
+									add(rulePegText, position338)
+								}
+								{
+									add(ruleAction47, position)
+								}
+							}
+						l335:
+							add(ruleDoubleChar, position334)
+						}
+						goto l333
+					l332:
+						expectRule(ruleDoubleChar, begin332, mark332)
+						goto l326
 					}
+				l333:
 				}
-			l180:
-				add(ruleDoubleRange, position179)
+			l328:
+				add(ruleDoubleRange, position327)
 			}
-			memoize(19, position178, tokenIndex178, true)
+			memoize(26, position326, tokenIndex326, reach326, true)
 			return true
-		l178:
-			memoize(19, position178, tokenIndex178, false)
-			position, tokenIndex = position178, tokenIndex178
+		l326:
+			expectRule(ruleDoubleRange, position326, mark326)
+			memoize(26, position326, tokenIndex326, reach326, false)
+			position, tokenIndex = position326, tokenIndex326
 			return false
 		},
-		/* 20 Char <- <(Escape / (!'\\' <.> Action30))> */
+		/* 27 Property <- <(<('\\' ('p' / 'P') '{' ((&('_') '_') | (&('A' | 'B' | 'C' | 'D' | 'E' | 'F' | 'G' | 'H' | 'I' | 'J' | 'K' | 'L' | 'M' | 'N' | 'O' | 'P' | 'Q' | 'R' | 'S' | 'T' | 'U' | 'V' | 'W' | 'X' | 'Y' | 'Z') [A-Z]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f' | 'g' | 'h' | 'i' | 'j' | 'k' | 'l' | 'm' | 'n' | 'o' | 'p' | 'q' | 'r' | 's' | 't' | 'u' | 'v' | 'w' | 'x' | 'y' | 'z') [a-z]))+ '}')> Action45)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{20, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position183, tokenIndex183 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{27, position}); ok {
+				return memoizedResult(ruleProperty, memoized)
+			}
+			position340, tokenIndex340 := position, tokenIndex
+			reach340 := reach
+			reach = position
+			mark340 := expectMark()
 			{
-				position184 := position
+				position341 := position
 				{
-					position185, tokenIndex185 := position, tokenIndex
-					if !_rules[ruleEscape]() {
-						goto l186
+					position342 := position
+					if buffer[position] != '\\' {
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l340
 					}
-					goto l185
-				l186:
-					position, tokenIndex = position185, tokenIndex185
+					position++
 					{
-						position187, tokenIndex187 := position, tokenIndex
-						if buffer[position] != '\\' {
-							goto l187
+						position343, tokenIndex343 := position, tokenIndex
+						if buffer[position] != 'p' {
+							if position >= farthest || position >= reach {
+								expect("'p'")
+							}
+							goto l344
+						}
+						position++
+						goto l343
+					l344:
+						position, tokenIndex = position343, tokenIndex343
+						if buffer[position] != 'P' {
+							if position >= farthest || position >= reach {
+								expect("'P'")
+							}
+							goto l340
 						}
 						position++
-						goto l183
-					l187:
-						position, tokenIndex = position187, tokenIndex187
 					}
+				l343:
+					if buffer[position] != '{' {
+						if position >= farthest || position >= reach {
+							expect("'{'")
+						}
+						goto l340
+					}
+					position++
 					{
-						position188 := position
-						if !matchDot() {
-							goto l183
+						switch buffer[position] {
+						case '_':
+							position++
+						case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
+							position++
+						default:
+							if position >= farthest || position >= reach {
+								expect("'_'")
+							}
+							if position >= farthest || position >= reach {
+								expect("[A-Z]")
+							}
+							if c := buffer[position]; c < 'a' || c > 'z' {
+								if position >= farthest || position >= reach {
+									expect("[a-z]")
+								}
+								goto l340
+							}
+							position++
 						}
-						add(rulePegText, position188)
 					}
+
+				l345:
 					{
-						add(ruleAction30, position)
+						position346, tokenIndex346 := position, tokenIndex
+						if limited && !step() {
+							goto l346
+						}
+						{
+							switch buffer[position] {
+							case '_':
+								position++
+							case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z':
+								position++
+							default:
+								if position >= farthest || position >= reach {
+									expect("'_'")
+								}
+								if position >= farthest || position >= reach {
+									expect("[A-Z]")
+								}
+								if c := buffer[position]; c < 'a' || c > 'z' {
+									if position >= farthest || position >= reach {
+										expect("[a-z]")
+									}
+									goto l346
+								}
+								position++
+							}
+						}
+
+						goto l345
+					l346:
+						position, tokenIndex = position346, tokenIndex346
+					}
+					if buffer[position] != '}' {
+						if position >= farthest || position >= reach {
+							expect("'}'")
+						}
+						goto l340
 					}
+					position++
+					add(rulePegText, position342)
+				}
+				{
+					add(ruleAction45, position)
 				}
-			l185:
-				add(ruleChar, position184)
+				add(ruleProperty, position341)
 			}
-			memoize(20, position183, tokenIndex183, true)
+			memoize(27, position340, tokenIndex340, reach340, true)
 			return true
-		l183:
-			memoize(20, position183, tokenIndex183, false)
-			position, tokenIndex = position183, tokenIndex183
+		l340:
+			expectRule(ruleProperty, position340, mark340)
+			memoize(27, position340, tokenIndex340, reach340, false)
+			position, tokenIndex = position340, tokenIndex340
 			return false
 		},
-		/* 21 DoubleChar <- <(Escape / (<([a-z] / [A-Z])> Action31) / (!'\\' <.> Action32))> */
+		/* 28 Char <- <(Escape / (!'\\' <.> Action46))> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{21, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{28, position}); ok {
+				return memoizedResult(ruleChar, memoized)
 			}
-			position190, tokenIndex190 := position, tokenIndex
+			position350, tokenIndex350 := position, tokenIndex
+			reach350 := reach
+			reach = position
+			mark350 := expectMark()
 			{
-				position191 := position
+				position351 := position
 				{
-					position192, tokenIndex192 := position, tokenIndex
+					position352, tokenIndex352 := position, tokenIndex
 					if !_rules[ruleEscape]() {
-						goto l193
+						goto l353
 					}
-					goto l192
-				l193:
-					position, tokenIndex = position192, tokenIndex192
+					goto l352
+				l353:
+					position, tokenIndex = position352, tokenIndex352
 					{
-						position195 := position
-						{
-							position196, tokenIndex196 := position, tokenIndex
-							if c := buffer[position]; c < 'a' || c > 'z' {
-								goto l197
-							}
-							position++
-							goto l196
-						l197:
-							position, tokenIndex = position196, tokenIndex196
-							if c := buffer[position]; c < 'A' || c > 'Z' {
-								goto l194
-							}
-							position++
-						}
-					l196:
-						add(rulePegText, position195)
-					}
-					{
-						add(ruleAction31, position)
-					}
-					goto l192
-				l194:
-					position, tokenIndex = position192, tokenIndex192
-					{
-						position199, tokenIndex199 := position, tokenIndex
+						position354, tokenIndex354 := position, tokenIndex
 						if buffer[position] != '\\' {
-							goto l199
+							reach = max(reach, position+1)
+							goto l354
 						}
 						position++
-						goto l190
-					l199:
-						position, tokenIndex = position199, tokenIndex199
+						reach = max(reach, position)
+						goto l350
+					l354:
+						position, tokenIndex = position354, tokenIndex354
 					}
 					{
-						position200 := position
+						position355 := position
 						if !matchDot() {
-							goto l190
+							if position >= farthest || position >= reach {
+								expect("any character")
+							}
+							goto l350
 						}
-						add(rulePegText, position200)
+						add(rulePegText, position355)
 					}
 					{
-						add(ruleAction32, position)
+						add(ruleAction46, position)
 					}
 				}
-			l192:
-				add(ruleDoubleChar, position191)
+			l352:
+				add(ruleChar, position351)
 			}
-			memoize(21, position190, tokenIndex190, true)
+			memoize(28, position350, tokenIndex350, reach350, true)
 			return true
-		l190:
-			memoize(21, position190, tokenIndex190, false)
-			position, tokenIndex = position190, tokenIndex190
+		l350:
+			expectRule(ruleChar, position350, mark350)
+			memoize(28, position350, tokenIndex350, reach350, false)
+			position, tokenIndex = position350, tokenIndex350
 			return false
 		},
-		/* 22 Escape <- <(('\\' ('a' / 'A') Action33) / ('\\' ('b' / 'B') Action34) / ('\\' ('e' / 'E') Action35) / ('\\' ('f' / 'F') Action36) / ('\\' ('n' / 'N') Action37) / ('\\' ('r' / 'R') Action38) / ('\\' ('t' / 'T') Action39) / ('\\' ('v' / 'V') Action40) / ('\\' '\'' Action41) / ('\\' '"' Action42) / ('\\' '[' Action43) / ('\\' ']' Action44) / ('\\' '-' Action45) / ('\\' ('0' ('x' / 'X')) <((&('A' | 'B' | 'C' | 'D' | 'E' | 'F') [A-F]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f') [a-f]) | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]))+> Action46) / ('\\' <([0-3] [0-7] [0-7])> Action47) / ('\\' <([0-7] [0-7]?)> Action48) / ('\\' '\\' Action49))> */
+		/* 29 DoubleChar <- <(Escape / (!'\\' <.> Action47))> */
+		nil,
+		/* 30 Escape <- <(("\\a" Action48) / ("\\b" Action49) / ("\\e" Action50) / ("\\f" Action51) / ("\\n" Action52) / ("\\r" Action53) / ("\\t" Action54) / ("\\v" Action55) / ("\\'" Action56) / ('\\' '"' Action57) / ('\\' '[' Action58) / ('\\' ']' Action59) / ('\\' '-' Action60) / ('\\' 'x' <(((&('A' | 'B' | 'C' | 'D' | 'E' | 'F') [A-F]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f') [a-f]) | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9])) ((&('A' | 'B' | 'C' | 'D' | 'E' | 'F') [A-F]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f') [a-f]) | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9])))> Action61) / ('\\' "0x" <((&('A' | 'B' | 'C' | 'D' | 'E' | 'F') [A-F]) | (&('a' | 'b' | 'c' | 'd' | 'e' | 'f') [a-f]) | (&('0' | '1' | '2' | '3' | '4' | '5' | '6' | '7' | '8' | '9') [0-9]))+> Action62) / ('\\' <([0-3] [0-7] [0-7])> Action63) / ('\\' <([0-7] [0-7]?)> Action64) / ('\\' '\\' Action65))> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{22, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position202, tokenIndex202 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{30, position}); ok {
+				return memoizedResult(ruleEscape, memoized)
+			}
+			position358, tokenIndex358 := position, tokenIndex
+			reach358 := reach
+			reach = position
+			mark358 := expectMark()
 			{
-				position203 := position
+				position359 := position
 				{
-					position204, tokenIndex204 := position, tokenIndex
-					if buffer[position] != '\\' {
-						goto l205
-					}
-					position++
-					{
-						position206, tokenIndex206 := position, tokenIndex
-						if buffer[position] != 'a' {
-							goto l207
-						}
-						position++
-						goto l206
-					l207:
-						position, tokenIndex = position206, tokenIndex206
-						if buffer[position] != 'A' {
-							goto l205
+					position360, tokenIndex360 := position, tokenIndex
+					if !matchCaseInsensitive("\\a") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\a\"")
 						}
-						position++
+						goto l361
 					}
-				l206:
 					{
-						add(ruleAction33, position)
-					}
-					goto l204
-				l205:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l209
+						add(ruleAction48, position)
 					}
-					position++
-					{
-						position210, tokenIndex210 := position, tokenIndex
-						if buffer[position] != 'b' {
-							goto l211
-						}
-						position++
-						goto l210
-					l211:
-						position, tokenIndex = position210, tokenIndex210
-						if buffer[position] != 'B' {
-							goto l209
+					goto l360
+				l361:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\b") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\b\"")
 						}
-						position++
+						goto l363
 					}
-				l210:
 					{
-						add(ruleAction34, position)
-					}
-					goto l204
-				l209:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l213
+						add(ruleAction49, position)
 					}
-					position++
-					{
-						position214, tokenIndex214 := position, tokenIndex
-						if buffer[position] != 'e' {
-							goto l215
-						}
-						position++
-						goto l214
-					l215:
-						position, tokenIndex = position214, tokenIndex214
-						if buffer[position] != 'E' {
-							goto l213
+					goto l360
+				l363:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\e") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\e\"")
 						}
-						position++
-					}
-				l214:
-					{
-						add(ruleAction35, position)
+						goto l365
 					}
-					goto l204
-				l213:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l217
-					}
-					position++
 					{
-						position218, tokenIndex218 := position, tokenIndex
-						if buffer[position] != 'f' {
-							goto l219
-						}
-						position++
-						goto l218
-					l219:
-						position, tokenIndex = position218, tokenIndex218
-						if buffer[position] != 'F' {
-							goto l217
+						add(ruleAction50, position)
+					}
+					goto l360
+				l365:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\f") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\f\"")
 						}
-						position++
-					}
-				l218:
-					{
-						add(ruleAction36, position)
+						goto l367
 					}
-					goto l204
-				l217:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l221
-					}
-					position++
 					{
-						position222, tokenIndex222 := position, tokenIndex
-						if buffer[position] != 'n' {
-							goto l223
-						}
-						position++
-						goto l222
-					l223:
-						position, tokenIndex = position222, tokenIndex222
-						if buffer[position] != 'N' {
-							goto l221
+						add(ruleAction51, position)
+					}
+					goto l360
+				l367:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\n") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\n\"")
 						}
-						position++
+						goto l369
 					}
-				l222:
 					{
-						add(ruleAction37, position)
-					}
-					goto l204
-				l221:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l225
+						add(ruleAction52, position)
+					}
+					goto l360
+				l369:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\r") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\r\"")
+						}
+						goto l371
 					}
-					position++
 					{
-						position226, tokenIndex226 := position, tokenIndex
-						if buffer[position] != 'r' {
-							goto l227
-						}
-						position++
-						goto l226
-					l227:
-						position, tokenIndex = position226, tokenIndex226
-						if buffer[position] != 'R' {
-							goto l225
+						add(ruleAction53, position)
+					}
+					goto l360
+				l371:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\t") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\t\"")
 						}
-						position++
+						goto l373
 					}
-				l226:
 					{
-						add(ruleAction38, position)
-					}
-					goto l204
-				l225:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l229
+						add(ruleAction54, position)
+					}
+					goto l360
+				l373:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\v") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\v\"")
+						}
+						goto l375
 					}
-					position++
 					{
-						position230, tokenIndex230 := position, tokenIndex
-						if buffer[position] != 't' {
-							goto l231
-						}
-						position++
-						goto l230
-					l231:
-						position, tokenIndex = position230, tokenIndex230
-						if buffer[position] != 'T' {
-							goto l229
+						add(ruleAction55, position)
+					}
+					goto l360
+				l375:
+					position, tokenIndex = position360, tokenIndex360
+					if !matchCaseInsensitive("\\'") {
+						if position >= farthest || position >= reach {
+							expect("\"\\\\'\"")
 						}
-						position++
+						goto l377
 					}
-				l230:
 					{
-						add(ruleAction39, position)
+						add(ruleAction56, position)
 					}
-					goto l204
-				l229:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l377:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l233
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l379
 					}
 					position++
-					{
-						position234, tokenIndex234 := position, tokenIndex
-						if buffer[position] != 'v' {
-							goto l235
-						}
-						position++
-						goto l234
-					l235:
-						position, tokenIndex = position234, tokenIndex234
-						if buffer[position] != 'V' {
-							goto l233
+					if buffer[position] != '"' {
+						if position >= farthest || position >= reach {
+							expect("'\"'")
 						}
-						position++
+						goto l379
 					}
-				l234:
+					position++
 					{
-						add(ruleAction40, position)
+						add(ruleAction57, position)
 					}
-					goto l204
-				l233:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l379:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l237
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l381
 					}
 					position++
-					if buffer[position] != '\'' {
-						goto l237
+					if buffer[position] != '[' {
+						if position >= farthest || position >= reach {
+							expect("'['")
+						}
+						goto l381
 					}
 					position++
 					{
-						add(ruleAction41, position)
+						add(ruleAction58, position)
 					}
-					goto l204
-				l237:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l381:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l239
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l383
 					}
 					position++
-					if buffer[position] != '"' {
-						goto l239
+					if buffer[position] != ']' {
+						if position >= farthest || position >= reach {
+							expect("']'")
+						}
+						goto l383
 					}
 					position++
 					{
-						add(ruleAction42, position)
+						add(ruleAction59, position)
 					}
-					goto l204
-				l239:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l383:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l241
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l385
 					}
 					position++
-					if buffer[position] != '[' {
-						goto l241
+					if buffer[position] != '-' {
+						if position >= farthest || position >= reach {
+							expect("'-'")
+						}
+						goto l385
 					}
 					position++
 					{
-						add(ruleAction43, position)
+						add(ruleAction60, position)
 					}
-					goto l204
-				l241:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l385:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l243
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l387
 					}
 					position++
-					if buffer[position] != ']' {
-						goto l243
+					if buffer[position] != 'x' {
+						if position >= farthest || position >= reach {
+							expect("'x'")
+						}
+						goto l387
 					}
 					position++
 					{
-						add(ruleAction44, position)
-					}
-					goto l204
-				l243:
-					position, tokenIndex = position204, tokenIndex204
-					if buffer[position] != '\\' {
-						goto l245
-					}
-					position++
-					if buffer[position] != '-' {
-						goto l245
+						position388 := position
+						{
+							switch buffer[position] {
+							case 'A', 'B', 'C', 'D', 'E', 'F':
+								position++
+							case 'a', 'b', 'c', 'd', 'e', 'f':
+								position++
+							default:
+								if position >= farthest || position >= reach {
+									expect("[A-F]")
+								}
+								if position >= farthest || position >= reach {
+									expect("[a-f]")
+								}
+								if c := buffer[position]; c < '0' || c > '9' {
+									if position >= farthest || position >= reach {
+										expect("[0-9]")
+									}
+									goto l387
+								}
+								position++
+							}
+						}
+
+						{
+							switch buffer[position] {
+							case 'A', 'B', 'C', 'D', 'E', 'F':
+								position++
+							case 'a', 'b', 'c', 'd', 'e', 'f':
+								position++
+							default:
+								if position >= farthest || position >= reach {
+									expect("[A-F]")
+								}
+								if position >= farthest || position >= reach {
+									expect("[a-f]")
+								}
+								if c := buffer[position]; c < '0' || c > '9' {
+									if position >= farthest || position >= reach {
+										expect("[0-9]")
+									}
+									goto l387
+								}
+								position++
+							}
+						}
+
+						add(rulePegText, position388)
 					}
-					position++
 					{
-						add(ruleAction45, position)
+						add(ruleAction61, position)
 					}
-					goto l204
-				l245:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l387:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l247
-					}
-					position++
-					if buffer[position] != '0' {
-						goto l247
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l392
 					}
 					position++
-					{
-						position248, tokenIndex248 := position, tokenIndex
-						if buffer[position] != 'x' {
-							goto l249
-						}
-						position++
-						goto l248
-					l249:
-						position, tokenIndex = position248, tokenIndex248
-						if buffer[position] != 'X' {
-							goto l247
+					if !matchCaseInsensitive("0x") {
+						if position >= farthest || position >= reach {
+							expect("\"0x\"")
 						}
-						position++
+						goto l392
 					}
-				l248:
 					{
-						position250 := position
+						position393 := position
 						{
 							switch buffer[position] {
 							case 'A', 'B', 'C', 'D', 'E', 'F':
@@ -2403,16 +5234,28 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 							case 'a', 'b', 'c', 'd', 'e', 'f':
 								position++
 							default:
+								if position >= farthest || position >= reach {
+									expect("[A-F]")
+								}
+								if position >= farthest || position >= reach {
+									expect("[a-f]")
+								}
 								if c := buffer[position]; c < '0' || c > '9' {
-									goto l247
+									if position >= farthest || position >= reach {
+										expect("[0-9]")
+									}
+									goto l392
 								}
 								position++
 							}
 						}
 
-					l251:
+					l394:
 						{
-							position252, tokenIndex252 := position, tokenIndex
+							position395, tokenIndex395 := position, tokenIndex
+							if limited && !step() {
+								goto l395
+							}
 							{
 								switch buffer[position] {
 								case 'A', 'B', 'C', 'D', 'E', 'F':
@@ -2420,352 +5263,770 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 								case 'a', 'b', 'c', 'd', 'e', 'f':
 									position++
 								default:
+									if position >= farthest || position >= reach {
+										expect("[A-F]")
+									}
+									if position >= farthest || position >= reach {
+										expect("[a-f]")
+									}
 									if c := buffer[position]; c < '0' || c > '9' {
-										goto l252
+										if position >= farthest || position >= reach {
+											expect("[0-9]")
+										}
+										goto l395
 									}
 									position++
 								}
 							}
 
-							goto l251
-						l252:
-							position, tokenIndex = position252, tokenIndex252
+							goto l394
+						l395:
+							position, tokenIndex = position395, tokenIndex395
 						}
-						add(rulePegText, position250)
+						add(rulePegText, position393)
 					}
 					{
-						add(ruleAction46, position)
+						add(ruleAction62, position)
 					}
-					goto l204
-				l247:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l392:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l256
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l399
 					}
 					position++
 					{
-						position257 := position
+						position400 := position
 						if c := buffer[position]; c < '0' || c > '3' {
-							goto l256
+							if position >= farthest || position >= reach {
+								expect("[0-3]")
+							}
+							goto l399
 						}
 						position++
 						if c := buffer[position]; c < '0' || c > '7' {
-							goto l256
+							if position >= farthest || position >= reach {
+								expect("[0-7]")
+							}
+							goto l399
 						}
 						position++
 						if c := buffer[position]; c < '0' || c > '7' {
-							goto l256
+							if position >= farthest || position >= reach {
+								expect("[0-7]")
+							}
+							goto l399
 						}
 						position++
-						add(rulePegText, position257)
+						add(rulePegText, position400)
 					}
 					{
-						add(ruleAction47, position)
+						add(ruleAction63, position)
 					}
-					goto l204
-				l256:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l399:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l259
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l402
 					}
 					position++
 					{
-						position260 := position
+						position403 := position
 						if c := buffer[position]; c < '0' || c > '7' {
-							goto l259
+							if position >= farthest || position >= reach {
+								expect("[0-7]")
+							}
+							goto l402
 						}
 						position++
 						{
-							position261, tokenIndex261 := position, tokenIndex
+							position404, tokenIndex404 := position, tokenIndex
 							if c := buffer[position]; c < '0' || c > '7' {
-								goto l261
+								if position >= farthest || position >= reach {
+									expect("[0-7]")
+								}
+								goto l404
 							}
 							position++
-							goto l262
-						l261:
-							position, tokenIndex = position261, tokenIndex261
+							goto l405
+						l404:
+							position, tokenIndex = position404, tokenIndex404
 						}
-					l262:
-						add(rulePegText, position260)
+					l405:
+						add(rulePegText, position403)
 					}
 					{
-						add(ruleAction48, position)
+						add(ruleAction64, position)
 					}
-					goto l204
-				l259:
-					position, tokenIndex = position204, tokenIndex204
+					goto l360
+				l402:
+					position, tokenIndex = position360, tokenIndex360
 					if buffer[position] != '\\' {
-						goto l202
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l358
 					}
 					position++
 					if buffer[position] != '\\' {
-						goto l202
+						if position >= farthest || position >= reach {
+							expect("'\\\\'")
+						}
+						goto l358
 					}
 					position++
 					{
-						add(ruleAction49, position)
+						add(ruleAction65, position)
 					}
 				}
-			l204:
-				add(ruleEscape, position203)
+			l360:
+				add(ruleEscape, position359)
 			}
-			memoize(22, position202, tokenIndex202, true)
+			memoize(30, position358, tokenIndex358, reach358, true)
 			return true
-		l202:
-			memoize(22, position202, tokenIndex202, false)
-			position, tokenIndex = position202, tokenIndex202
+		l358:
+			expectRule(ruleEscape, position358, mark358)
+			memoize(30, position358, tokenIndex358, reach358, false)
+			position, tokenIndex = position358, tokenIndex358
 			return false
 		},
-		/* 23 LeftArrow <- <((('<' '-') / '←') Spacing)> */
+		/* 31 LeftArrow <- <((('<' '-') / '←') Spacing)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{23, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{31, position}); ok {
+				return memoizedResult(ruleLeftArrow, memoized)
 			}
-			position265, tokenIndex265 := position, tokenIndex
+			position408, tokenIndex408 := position, tokenIndex
+			reach408 := reach
+			reach = position
+			mark408 := expectMark()
 			{
-				position266 := position
+				position409 := position
 				{
-					position267, tokenIndex267 := position, tokenIndex
+					position410, tokenIndex410 := position, tokenIndex
 					if buffer[position] != '<' {
-						goto l268
+						if position >= farthest || position >= reach {
+							expect("'<'")
+						}
+						goto l411
 					}
 					position++
 					if buffer[position] != '-' {
-						goto l268
+						if position >= farthest || position >= reach {
+							expect("'-'")
+						}
+						goto l411
 					}
 					position++
-					goto l267
-				l268:
-					position, tokenIndex = position267, tokenIndex267
+					goto l410
+				l411:
+					position, tokenIndex = position410, tokenIndex410
 					if buffer[position] != '←' {
-						goto l265
+						if position >= farthest || position >= reach {
+							expect("'←'")
+						}
+						goto l408
 					}
 					position++
 				}
-			l267:
+			l410:
+				silent++
 				_rules[ruleSpacing]()
-				add(ruleLeftArrow, position266)
+				silent--
+				add(ruleLeftArrow, position409)
 			}
-			memoize(23, position265, tokenIndex265, true)
+			memoize(31, position408, tokenIndex408, reach408, true)
 			return true
-		l265:
-			memoize(23, position265, tokenIndex265, false)
-			position, tokenIndex = position265, tokenIndex265
+		l408:
+			expectRule(ruleLeftArrow, position408, mark408)
+			memoize(31, position408, tokenIndex408, reach408, false)
+			position, tokenIndex = position408, tokenIndex408
 			return false
 		},
-		/* 24 Slash <- <('/' Spacing)> */
+		/* 32 Slash <- <('/' Spacing)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{24, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{32, position}); ok {
+				return memoizedResult(ruleSlash, memoized)
 			}
-			position269, tokenIndex269 := position, tokenIndex
+			position412, tokenIndex412 := position, tokenIndex
+			reach412 := reach
+			reach = position
+			mark412 := expectMark()
 			{
-				position270 := position
+				position413 := position
 				if buffer[position] != '/' {
-					goto l269
+					if position >= farthest || position >= reach {
+						expect("'/'")
+					}
+					goto l412
 				}
 				position++
+				silent++
 				_rules[ruleSpacing]()
-				add(ruleSlash, position270)
+				silent--
+				add(ruleSlash, position413)
 			}
-			memoize(24, position269, tokenIndex269, true)
+			memoize(32, position412, tokenIndex412, reach412, true)
 			return true
-		l269:
-			memoize(24, position269, tokenIndex269, false)
-			position, tokenIndex = position269, tokenIndex269
+		l412:
+			expectRule(ruleSlash, position412, mark412)
+			memoize(32, position412, tokenIndex412, reach412, false)
+			position, tokenIndex = position412, tokenIndex412
 			return false
 		},
-		/* 25 And <- <('&' Spacing)> */
+		/* 33 And <- <('&' Spacing)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{25, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{33, position}); ok {
+				return memoizedResult(ruleAnd, memoized)
 			}
-			position271, tokenIndex271 := position, tokenIndex
+			position414, tokenIndex414 := position, tokenIndex
+			reach414 := reach
+			reach = position
+			mark414 := expectMark()
 			{
-				position272 := position
+				position415 := position
 				if buffer[position] != '&' {
-					goto l271
+					if position >= farthest || position >= reach {
+						expect("'&'")
+					}
+					goto l414
 				}
 				position++
+				silent++
 				_rules[ruleSpacing]()
-				add(ruleAnd, position272)
+				silent--
+				add(ruleAnd, position415)
 			}
-			memoize(25, position271, tokenIndex271, true)
+			memoize(33, position414, tokenIndex414, reach414, true)
 			return true
-		l271:
-			memoize(25, position271, tokenIndex271, false)
-			position, tokenIndex = position271, tokenIndex271
+		l414:
+			expectRule(ruleAnd, position414, mark414)
+			memoize(33, position414, tokenIndex414, reach414, false)
+			position, tokenIndex = position414, tokenIndex414
 			return false
 		},
-		/* 26 Not <- <('!' Spacing)> */
+		/* 34 Not <- <('!' Spacing)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{26, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position273, tokenIndex273 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{34, position}); ok {
+				return memoizedResult(ruleNot, memoized)
+			}
+			position416, tokenIndex416 := position, tokenIndex
+			reach416 := reach
+			reach = position
+			mark416 := expectMark()
 			{
-				position274 := position
+				position417 := position
 				if buffer[position] != '!' {
-					goto l273
+					if position >= farthest || position >= reach {
+						expect("'!'")
+					}
+					goto l416
 				}
 				position++
+				silent++
 				_rules[ruleSpacing]()
-				add(ruleNot, position274)
+				silent--
+				add(ruleNot, position417)
 			}
-			memoize(26, position273, tokenIndex273, true)
+			memoize(34, position416, tokenIndex416, reach416, true)
 			return true
-		l273:
-			memoize(26, position273, tokenIndex273, false)
-			position, tokenIndex = position273, tokenIndex273
+		l416:
+			expectRule(ruleNot, position416, mark416)
+			memoize(34, position416, tokenIndex416, reach416, false)
+			position, tokenIndex = position416, tokenIndex416
 			return false
 		},
-		/* 27 Question <- <('?' Spacing)> */
-		nil,
-		/* 28 Star <- <('*' Spacing)> */
+		/* 35 Question <- <('?' Spacing)> */
 		nil,
-		/* 29 Plus <- <('+' Spacing)> */
+		/* 36 Star <- <('*' Spacing)> */
 		nil,
-		/* 30 Open <- <('(' Spacing)> */
+		/* 37 Plus <- <('+' Spacing)> */
 		nil,
-		/* 31 Close <- <(')' Spacing)> */
+		/* 38 Caret <- <('^' Spacing)> */
 		nil,
-		/* 32 Dot <- <('.' Spacing)> */
+		/* 39 Tilde <- <('~' Spacing)> */
 		nil,
-		/* 33 SpaceComment <- <(Space / Comment)> */
+		/* 40 ResultType <- <(!LeftArrow '<' Spacing <(!'>' !EndOfLine .)+> '>' Spacing)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{33, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position281, tokenIndex281 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{40, position}); ok {
+				return memoizedResult(ruleResultType, memoized)
+			}
+			position423, tokenIndex423 := position, tokenIndex
+			reach423 := reach
+			reach = position
+			mark423 := expectMark()
 			{
-				position282 := position
+				position424 := position
 				{
-					position283, tokenIndex283 := position, tokenIndex
-					if !_rules[ruleSpace]() {
-						goto l284
+					position425, tokenIndex425 := position, tokenIndex
+					silent++
+					if !_rules[ruleLeftArrow]() {
+						goto l425
+					}
+					silent--
+					reach = max(reach, position)
+					goto l423
+				l425:
+					silent--
+					position, tokenIndex = position425, tokenIndex425
+				}
+				if buffer[position] != '<' {
+					if position >= farthest || position >= reach {
+						expect("'<'")
+					}
+					goto l423
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				{
+					position426 := position
+					{
+						position429, tokenIndex429 := position, tokenIndex
+						if buffer[position] != '>' {
+							reach = max(reach, position+1)
+							goto l429
+						}
+						position++
+						reach = max(reach, position)
+						goto l423
+					l429:
+						position, tokenIndex = position429, tokenIndex429
 					}
-					goto l283
-				l284:
-					position, tokenIndex = position283, tokenIndex283
 					{
-						position285 := position
+						position430, tokenIndex430 := position, tokenIndex
+						silent++
+						if !_rules[ruleEndOfLine]() {
+							goto l430
+						}
+						silent--
+						reach = max(reach, position)
+						goto l423
+					l430:
+						silent--
+						position, tokenIndex = position430, tokenIndex430
+					}
+					if !matchDot() {
+						if position >= farthest || position >= reach {
+							expect("any character")
+						}
+						goto l423
+					}
+				l427:
+					{
+						position428, tokenIndex428 := position, tokenIndex
+						if limited && !step() {
+							goto l428
+						}
 						{
-							position286, tokenIndex286 := position, tokenIndex
-							if buffer[position] != '#' {
-								goto l287
+							position431, tokenIndex431 := position, tokenIndex
+							if buffer[position] != '>' {
+								reach = max(reach, position+1)
+								goto l431
 							}
 							position++
-							goto l286
-						l287:
-							position, tokenIndex = position286, tokenIndex286
-							if buffer[position] != '/' {
-								goto l281
+							reach = max(reach, position)
+							goto l428
+						l431:
+							position, tokenIndex = position431, tokenIndex431
+						}
+						{
+							position432, tokenIndex432 := position, tokenIndex
+							silent++
+							if !_rules[ruleEndOfLine]() {
+								goto l432
 							}
-							position++
-							if buffer[position] != '/' {
-								goto l281
+							silent--
+							reach = max(reach, position)
+							goto l428
+						l432:
+							silent--
+							position, tokenIndex = position432, tokenIndex432
+						}
+						if !matchDot() {
+							if position >= farthest || position >= reach {
+								expect("any character")
 							}
-							position++
+							goto l428
 						}
-					l286:
-					l288:
+						goto l427
+					l428:
+						position, tokenIndex = position428, tokenIndex428
+					}
+					add(rulePegText, position426)
+				}
+				if buffer[position] != '>' {
+					if position >= farthest || position >= reach {
+						expect("'>'")
+					}
+					goto l423
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleResultType, position424)
+			}
+			memoize(40, position423, tokenIndex423, reach423, true)
+			return true
+		l423:
+			expectRule(ruleResultType, position423, mark423)
+			memoize(40, position423, tokenIndex423, reach423, false)
+			position, tokenIndex = position423, tokenIndex423
+			return false
+		},
+		/* 41 Open <- <('(' Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{41, position}); ok {
+				return memoizedResult(ruleOpen, memoized)
+			}
+			position433, tokenIndex433 := position, tokenIndex
+			reach433 := reach
+			reach = position
+			mark433 := expectMark()
+			{
+				position434 := position
+				if buffer[position] != '(' {
+					if position >= farthest || position >= reach {
+						expect("'('")
+					}
+					goto l433
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleOpen, position434)
+			}
+			memoize(41, position433, tokenIndex433, reach433, true)
+			return true
+		l433:
+			expectRule(ruleOpen, position433, mark433)
+			memoize(41, position433, tokenIndex433, reach433, false)
+			position, tokenIndex = position433, tokenIndex433
+			return false
+		},
+		/* 42 Close <- <(')' Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{42, position}); ok {
+				return memoizedResult(ruleClose, memoized)
+			}
+			position435, tokenIndex435 := position, tokenIndex
+			reach435 := reach
+			reach = position
+			mark435 := expectMark()
+			{
+				position436 := position
+				if buffer[position] != ')' {
+					if position >= farthest || position >= reach {
+						expect("')'")
+					}
+					goto l435
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleClose, position436)
+			}
+			memoize(42, position435, tokenIndex435, reach435, true)
+			return true
+		l435:
+			expectRule(ruleClose, position435, mark435)
+			memoize(42, position435, tokenIndex435, reach435, false)
+			position, tokenIndex = position435, tokenIndex435
+			return false
+		},
+		/* 43 Comma <- <(',' Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{43, position}); ok {
+				return memoizedResult(ruleComma, memoized)
+			}
+			position437, tokenIndex437 := position, tokenIndex
+			reach437 := reach
+			reach = position
+			mark437 := expectMark()
+			{
+				position438 := position
+				if buffer[position] != ',' {
+					if position >= farthest || position >= reach {
+						expect("','")
+					}
+					goto l437
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleComma, position438)
+			}
+			memoize(43, position437, tokenIndex437, reach437, true)
+			return true
+		l437:
+			expectRule(ruleComma, position437, mark437)
+			memoize(43, position437, tokenIndex437, reach437, false)
+			position, tokenIndex = position437, tokenIndex437
+			return false
+		},
+		/* 44 Colon <- <(':' Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{44, position}); ok {
+				return memoizedResult(ruleColon, memoized)
+			}
+			position439, tokenIndex439 := position, tokenIndex
+			reach439 := reach
+			reach = position
+			mark439 := expectMark()
+			{
+				position440 := position
+				if buffer[position] != ':' {
+					if position >= farthest || position >= reach {
+						expect("':'")
+					}
+					goto l439
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleColon, position440)
+			}
+			memoize(44, position439, tokenIndex439, reach439, true)
+			return true
+		l439:
+			expectRule(ruleColon, position439, mark439)
+			memoize(44, position439, tokenIndex439, reach439, false)
+			position, tokenIndex = position439, tokenIndex439
+			return false
+		},
+		/* 45 Dot <- <('.' Spacing)> */
+		nil,
+		/* 46 SpaceComment <- <(Space / Comment)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{46, position}); ok {
+				return memoizedResult(ruleSpaceComment, memoized)
+			}
+			position442, tokenIndex442 := position, tokenIndex
+			reach442 := reach
+			reach = position
+			mark442 := expectMark()
+			{
+				position443 := position
+				{
+					position444, tokenIndex444 := position, tokenIndex
+					if !_rules[ruleSpace]() {
+						goto l445
+					}
+					goto l444
+				l445:
+					position, tokenIndex = position444, tokenIndex444
+					{
+						begin446, mark446 := position, expectMark()
 						{
-							position289, tokenIndex289 := position, tokenIndex
+							position448 := position
+							{
+								position449, tokenIndex449 := position, tokenIndex
+								if buffer[position] != '#' {
+									if position >= farthest || position >= reach {
+										expect("'#'")
+									}
+									goto l450
+								}
+								position++
+								goto l449
+							l450:
+								position, tokenIndex = position449, tokenIndex449
+								if buffer[position] != '/' {
+									if position >= farthest || position >= reach {
+										expect("'/'")
+									}
+									goto l446
+								}
+								position++
+								if buffer[position] != '/' {
+									if position >= farthest || position >= reach {
+										expect("'/'")
+									}
+									goto l446
+								}
+								position++
+							}
+						l449:
+						l451:
 							{
-								position290, tokenIndex290 := position, tokenIndex
-								if !_rules[ruleEndOfLine]() {
-									goto l290
+								position452, tokenIndex452 := position, tokenIndex
+								if limited && !step() {
+									goto l452
+								}
+								{
+									position453, tokenIndex453 := position, tokenIndex
+									silent++
+									if !_rules[ruleEndOfLine]() {
+										goto l453
+									}
+									silent--
+									reach = max(reach, position)
+									goto l452
+								l453:
+									silent--
+									position, tokenIndex = position453, tokenIndex453
+								}
+								if !matchDot() {
+									if position >= farthest || position >= reach {
+										expect("any character")
+									}
+									goto l452
 								}
-								goto l289
-							l290:
-								position, tokenIndex = position290, tokenIndex290
+								goto l451
+							l452:
+								position, tokenIndex = position452, tokenIndex452
 							}
-							if !matchDot() {
-								goto l289
+							if !_rules[ruleEndOfLine]() {
+								goto l446
 							}
-							goto l288
-						l289:
-							position, tokenIndex = position289, tokenIndex289
-						}
-						if !_rules[ruleEndOfLine]() {
-							goto l281
+							add(ruleComment, position448)
 						}
-						add(ruleComment, position285)
+						goto l447
+					l446:
+						expectRule(ruleComment, begin446, mark446)
+						goto l442
 					}
+				l447:
 				}
-			l283:
-				add(ruleSpaceComment, position282)
+			l444:
+				add(ruleSpaceComment, position443)
 			}
-			memoize(33, position281, tokenIndex281, true)
+			memoize(46, position442, tokenIndex442, reach442, true)
 			return true
-		l281:
-			memoize(33, position281, tokenIndex281, false)
-			position, tokenIndex = position281, tokenIndex281
+		l442:
+			expectRule(ruleSpaceComment, position442, mark442)
+			memoize(46, position442, tokenIndex442, reach442, false)
+			position, tokenIndex = position442, tokenIndex442
 			return false
 		},
-		/* 34 Spacing <- <SpaceComment*> */
+		/* 47 Spacing <- <SpaceComment*> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{34, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position291, tokenIndex291 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{47, position}); ok {
+				return memoizedResult(ruleSpacing, memoized)
+			}
+			position454, tokenIndex454 := position, tokenIndex
+			reach454 := reach
+			reach = position
 			{
-				position292 := position
-			l293:
+				position455 := position
+			l456:
 				{
-					position294, tokenIndex294 := position, tokenIndex
+					position457, tokenIndex457 := position, tokenIndex
+					if limited && !step() {
+						goto l457
+					}
 					if !_rules[ruleSpaceComment]() {
-						goto l294
+						goto l457
 					}
-					goto l293
-				l294:
-					position, tokenIndex = position294, tokenIndex294
+					goto l456
+				l457:
+					position, tokenIndex = position457, tokenIndex457
 				}
-				add(ruleSpacing, position292)
+				add(ruleSpacing, position455)
 			}
-			memoize(34, position291, tokenIndex291, true)
+			memoize(47, position454, tokenIndex454, reach454, true)
 			return true
 		},
-		/* 35 MustSpacing <- <SpaceComment+> */
+		/* 48 MustSpacing <- <SpaceComment+> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{35, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position295, tokenIndex295 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{48, position}); ok {
+				return memoizedResult(ruleMustSpacing, memoized)
+			}
+			position458, tokenIndex458 := position, tokenIndex
+			reach458 := reach
+			reach = position
+			mark458 := expectMark()
 			{
-				position296 := position
+				position459 := position
 				if !_rules[ruleSpaceComment]() {
-					goto l295
+					goto l458
 				}
-			l297:
+			l460:
 				{
-					position298, tokenIndex298 := position, tokenIndex
+					position461, tokenIndex461 := position, tokenIndex
+					if limited && !step() {
+						goto l461
+					}
 					if !_rules[ruleSpaceComment]() {
-						goto l298
+						goto l461
 					}
-					goto l297
-				l298:
-					position, tokenIndex = position298, tokenIndex298
+					goto l460
+				l461:
+					position, tokenIndex = position461, tokenIndex461
 				}
-				add(ruleMustSpacing, position296)
+				add(ruleMustSpacing, position459)
 			}
-			memoize(35, position295, tokenIndex295, true)
+			memoize(48, position458, tokenIndex458, reach458, true)
 			return true
-		l295:
-			memoize(35, position295, tokenIndex295, false)
-			position, tokenIndex = position295, tokenIndex295
+		l458:
+			expectRule(ruleMustSpacing, position458, mark458)
+			memoize(48, position458, tokenIndex458, reach458, false)
+			position, tokenIndex = position458, tokenIndex458
 			return false
 		},
-		/* 36 Comment <- <(('#' / ('/' '/')) (!EndOfLine .)* EndOfLine)> */
+		/* 49 Comment <- <(('#' / ('/' '/')) (!EndOfLine .)* EndOfLine)> */
 		nil,
-		/* 37 Space <- <((&('\t') '\t') | (&(' ') ' ') | (&('\n' | '\r') EndOfLine))> */
+		/* 50 Space <- <((&('\t') '\t') | (&(' ') ' ') | (&('\n' | '\r') EndOfLine))> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{37, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position300, tokenIndex300 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{50, position}); ok {
+				return memoizedResult(ruleSpace, memoized)
+			}
+			position463, tokenIndex463 := position, tokenIndex
+			reach463 := reach
+			reach = position
+			mark463 := expectMark()
 			{
-				position301 := position
+				position464 := position
 				{
 					switch buffer[position] {
 					case '\t':
@@ -2773,288 +6034,452 @@ func (p *Peg[U]) Init(options ...func(*Peg[U]) error) error {
 					case ' ':
 						position++
 					default:
+						if position >= farthest || position >= reach {
+							expect("'\\t'")
+						}
+						if position >= farthest || position >= reach {
+							expect("' '")
+						}
 						if !_rules[ruleEndOfLine]() {
-							goto l300
+							goto l463
 						}
 					}
 				}
 
-				add(ruleSpace, position301)
+				add(ruleSpace, position464)
 			}
-			memoize(37, position300, tokenIndex300, true)
+			memoize(50, position463, tokenIndex463, reach463, true)
 			return true
-		l300:
-			memoize(37, position300, tokenIndex300, false)
-			position, tokenIndex = position300, tokenIndex300
+		l463:
+			expectRule(ruleSpace, position463, mark463)
+			memoize(50, position463, tokenIndex463, reach463, false)
+			position, tokenIndex = position463, tokenIndex463
 			return false
 		},
-		/* 38 Header <- <HeaderSpaceComment*> */
+		/* 51 Header <- <HeaderSpaceComment*> */
 		nil,
-		/* 39 HeaderSpaceComment <- <(HeaderComment / (<Space+> Action50))> */
+		/* 52 HeaderSpaceComment <- <(HeaderComment / (<Space+> Action66))> */
 		nil,
-		/* 40 HeaderComment <- <(('#' / ('/' '/')) <(!EndOfLine .)*> Action51 EndOfLine)> */
+		/* 53 HeaderComment <- <(('#' / ('/' '/')) <(!EndOfLine .)*> Action67 EndOfLine)> */
 		nil,
-		/* 41 EndOfLine <- <(('\r' '\n') / '\n' / '\r')> */
+		/* 54 EndOfLine <- <(('\r' '\n') / '\n' / '\r')> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{41, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
 			}
-			position306, tokenIndex306 := position, tokenIndex
+			if memoized, ok := memoization.get(memoKey[U]{54, position}); ok {
+				return memoizedResult(ruleEndOfLine, memoized)
+			}
+			position469, tokenIndex469 := position, tokenIndex
+			reach469 := reach
+			reach = position
+			mark469 := expectMark()
 			{
-				position307 := position
+				position470 := position
 				{
-					position308, tokenIndex308 := position, tokenIndex
+					position471, tokenIndex471 := position, tokenIndex
 					if buffer[position] != '\r' {
-						goto l309
+						if position >= farthest || position >= reach {
+							expect("'\\r'")
+						}
+						goto l472
 					}
 					position++
 					if buffer[position] != '\n' {
-						goto l309
+						if position >= farthest || position >= reach {
+							expect("'\\n'")
+						}
+						goto l472
 					}
 					position++
-					goto l308
-				l309:
-					position, tokenIndex = position308, tokenIndex308
+					goto l471
+				l472:
+					position, tokenIndex = position471, tokenIndex471
 					if buffer[position] != '\n' {
-						goto l310
+						if position >= farthest || position >= reach {
+							expect("'\\n'")
+						}
+						goto l473
 					}
 					position++
-					goto l308
-				l310:
-					position, tokenIndex = position308, tokenIndex308
+					goto l471
+				l473:
+					position, tokenIndex = position471, tokenIndex471
 					if buffer[position] != '\r' {
-						goto l306
+						if position >= farthest || position >= reach {
+							expect("'\\r'")
+						}
+						goto l469
 					}
 					position++
 				}
-			l308:
-				add(ruleEndOfLine, position307)
+			l471:
+				add(ruleEndOfLine, position470)
 			}
-			memoize(41, position306, tokenIndex306, true)
+			memoize(54, position469, tokenIndex469, reach469, true)
 			return true
-		l306:
-			memoize(41, position306, tokenIndex306, false)
-			position, tokenIndex = position306, tokenIndex306
+		l469:
+			expectRule(ruleEndOfLine, position469, mark469)
+			memoize(54, position469, tokenIndex469, reach469, false)
+			position, tokenIndex = position469, tokenIndex469
 			return false
 		},
-		/* 42 EndOfFile <- <!.> */
+		/* 55 EndOfFile <- <!.> */
 		nil,
-		/* 43 Action <- <('{' <ActionBody*> '}' Spacing)> */
+		/* 56 Action <- <('{' <ActionBody*> '}' Spacing)> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{43, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{56, position}); ok {
+				return memoizedResult(ruleAction, memoized)
 			}
-			position312, tokenIndex312 := position, tokenIndex
+			position475, tokenIndex475 := position, tokenIndex
+			reach475 := reach
+			reach = position
+			mark475 := expectMark()
 			{
-				position313 := position
+				position476 := position
 				if buffer[position] != '{' {
-					goto l312
+					if position >= farthest || position >= reach {
+						expect("'{'")
+					}
+					goto l475
 				}
 				position++
 				{
-					position314 := position
-				l315:
+					position477 := position
+				l478:
 					{
-						position316, tokenIndex316 := position, tokenIndex
+						position479, tokenIndex479 := position, tokenIndex
+						if limited && !step() {
+							goto l479
+						}
 						if !_rules[ruleActionBody]() {
-							goto l316
+							goto l479
 						}
-						goto l315
-					l316:
-						position, tokenIndex = position316, tokenIndex316
+						goto l478
+					l479:
+						position, tokenIndex = position479, tokenIndex479
 					}
-					add(rulePegText, position314)
+					add(rulePegText, position477)
 				}
 				if buffer[position] != '}' {
-					goto l312
+					if position >= farthest || position >= reach {
+						expect("'}'")
+					}
+					goto l475
 				}
 				position++
+				silent++
 				_rules[ruleSpacing]()
-				add(ruleAction, position313)
+				silent--
+				add(ruleAction, position476)
 			}
-			memoize(43, position312, tokenIndex312, true)
+			memoize(56, position475, tokenIndex475, reach475, true)
 			return true
-		l312:
-			memoize(43, position312, tokenIndex312, false)
-			position, tokenIndex = position312, tokenIndex312
+		l475:
+			expectRule(ruleAction, position475, mark475)
+			memoize(56, position475, tokenIndex475, reach475, false)
+			position, tokenIndex = position475, tokenIndex475
 			return false
 		},
-		/* 44 ActionBody <- <((!('{' / '}') .) / ('{' ActionBody* '}'))> */
+		/* 57 ActionBody <- <((!('{' / '}') .) / ('{' ActionBody* '}'))> */
 		func() bool {
-			if memoized, ok := memoization[memoKey[U]{44, position}]; ok {
-				return memoizedResult(memoized)
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{57, position}); ok {
+				return memoizedResult(ruleActionBody, memoized)
 			}
-			position317, tokenIndex317 := position, tokenIndex
+			position480, tokenIndex480 := position, tokenIndex
+			reach480 := reach
+			reach = position
+			mark480 := expectMark()
 			{
-				position318 := position
+				position481 := position
 				{
-					position319, tokenIndex319 := position, tokenIndex
+					position482, tokenIndex482 := position, tokenIndex
 					{
-						position321, tokenIndex321 := position, tokenIndex
+						position484, tokenIndex484 := position, tokenIndex
 						{
-							position322, tokenIndex322 := position, tokenIndex
+							position485, tokenIndex485 := position, tokenIndex
 							if buffer[position] != '{' {
-								goto l323
+								reach = max(reach, position+1)
+								goto l486
 							}
 							position++
-							goto l322
-						l323:
-							position, tokenIndex = position322, tokenIndex322
+							goto l485
+						l486:
+							position, tokenIndex = position485, tokenIndex485
 							if buffer[position] != '}' {
-								goto l321
+								reach = max(reach, position+1)
+								goto l484
 							}
 							position++
 						}
-					l322:
-						goto l320
-					l321:
-						position, tokenIndex = position321, tokenIndex321
+					l485:
+						reach = max(reach, position)
+						goto l483
+					l484:
+						position, tokenIndex = position484, tokenIndex484
 					}
 					if !matchDot() {
-						goto l320
+						if position >= farthest || position >= reach {
+							expect("any character")
+						}
+						goto l483
 					}
-					goto l319
-				l320:
-					position, tokenIndex = position319, tokenIndex319
+					goto l482
+				l483:
+					position, tokenIndex = position482, tokenIndex482
 					if buffer[position] != '{' {
-						goto l317
+						if position >= farthest || position >= reach {
+							expect("'{'")
+						}
+						goto l480
 					}
 					position++
-				l324:
+				l487:
 					{
-						position325, tokenIndex325 := position, tokenIndex
+						position488, tokenIndex488 := position, tokenIndex
+						if limited && !step() {
+							goto l488
+						}
 						if !_rules[ruleActionBody]() {
-							goto l325
+							goto l488
 						}
-						goto l324
-					l325:
-						position, tokenIndex = position325, tokenIndex325
+						goto l487
+					l488:
+						position, tokenIndex = position488, tokenIndex488
 					}
 					if buffer[position] != '}' {
-						goto l317
+						if position >= farthest || position >= reach {
+							expect("'}'")
+						}
+						goto l480
 					}
 					position++
 				}
-			l319:
-				add(ruleActionBody, position318)
+			l482:
+				add(ruleActionBody, position481)
+			}
+			memoize(57, position480, tokenIndex480, reach480, true)
+			return true
+		l480:
+			expectRule(ruleActionBody, position480, mark480)
+			memoize(57, position480, tokenIndex480, reach480, false)
+			position, tokenIndex = position480, tokenIndex480
+			return false
+		},
+		/* 58 Begin <- <('<' Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{58, position}); ok {
+				return memoizedResult(ruleBegin, memoized)
+			}
+			position489, tokenIndex489 := position, tokenIndex
+			reach489 := reach
+			reach = position
+			mark489 := expectMark()
+			{
+				position490 := position
+				if buffer[position] != '<' {
+					if position >= farthest || position >= reach {
+						expect("'<'")
+					}
+					goto l489
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleBegin, position490)
+			}
+			memoize(58, position489, tokenIndex489, reach489, true)
+			return true
+		l489:
+			expectRule(ruleBegin, position489, mark489)
+			memoize(58, position489, tokenIndex489, reach489, false)
+			position, tokenIndex = position489, tokenIndex489
+			return false
+		},
+		/* 59 End <- <('>' Spacing)> */
+		func() bool {
+			if limited && !step() {
+				return false
+			}
+			if memoized, ok := memoization.get(memoKey[U]{59, position}); ok {
+				return memoizedResult(ruleEnd, memoized)
+			}
+			position491, tokenIndex491 := position, tokenIndex
+			reach491 := reach
+			reach = position
+			mark491 := expectMark()
+			{
+				position492 := position
+				if buffer[position] != '>' {
+					if position >= farthest || position >= reach {
+						expect("'>'")
+					}
+					goto l491
+				}
+				position++
+				silent++
+				_rules[ruleSpacing]()
+				silent--
+				add(ruleEnd, position492)
 			}
-			memoize(44, position317, tokenIndex317, true)
+			memoize(59, position491, tokenIndex491, reach491, true)
 			return true
-		l317:
-			memoize(44, position317, tokenIndex317, false)
-			position, tokenIndex = position317, tokenIndex317
+		l491:
+			expectRule(ruleEnd, position491, mark491)
+			memoize(59, position491, tokenIndex491, reach491, false)
+			position, tokenIndex = position491, tokenIndex491
 			return false
 		},
-		/* 45 Begin <- <('<' Spacing)> */
+		/* 61 Action0 <- <{ p.AddPackage(text) }> */
+		nil,
+		/* 62 Action1 <- <{ p.AddPeg(text) }> */
+		nil,
+		/* 63 Action2 <- <{ p.AddState(text) }> */
+		nil,
+		/* 64 Action3 <- <{ p.AddImportAlias(text) }> */
+		nil,
+		nil,
+		/* 66 Action4 <- <{ p.AddImport(text) }> */
+		nil,
+		/* 67 Action5 <- <{ p.AddIncludeNamespace(text) }> */
+		nil,
+		/* 68 Action6 <- <{ p.AddInclude(text) }> */
+		nil,
+		/* 69 Action7 <- <{ p.AddAnnotation(text) }> */
+		nil,
+		/* 70 Action8 <- <{ p.AddRule(text) }> */
+		nil,
+		/* 71 Action9 <- <{ p.AddRule(text) }> */
+		nil,
+		/* 72 Action10 <- <{ p.AddResultType(text) }> */
+		nil,
+		/* 73 Action11 <- <{ p.AddExpression() }> */
+		nil,
+		/* 74 Action12 <- <{ p.AddParameter(text) }> */
+		nil,
+		/* 75 Action13 <- <{ p.AddAlternate() }> */
 		nil,
-		/* 46 End <- <('>' Spacing)> */
+		/* 76 Action14 <- <{ p.AddNil(); p.AddAlternate() }> */
 		nil,
-		/* 48 Action0 <- <{ p.AddPackage(text) }> */
+		/* 77 Action15 <- <{ p.AddNil() }> */
 		nil,
-		/* 49 Action1 <- <{ p.AddPeg(text) }> */
+		/* 78 Action16 <- <{ p.AddSequence() }> */
 		nil,
-		/* 50 Action2 <- <{ p.AddState(text) }> */
+		/* 79 Action17 <- <{ p.AddPredicate(text) }> */
 		nil,
-		/* 51 Action3 <- <{ p.AddImportAlias(text) }> */
+		/* 80 Action18 <- <{ p.AddStateChange(text) }> */
 		nil,
+		/* 81 Action19 <- <{ p.AddPeekFor() }> */
 		nil,
-		/* 53 Action4 <- <{ p.AddImport(text) }> */
+		/* 82 Action20 <- <{ p.AddPeekNot() }> */
 		nil,
-		/* 54 Action5 <- <{ p.AddRule(text) }> */
+		/* 83 Action21 <- <{ p.AddCommit() }> */
 		nil,
-		/* 55 Action6 <- <{ p.AddExpression() }> */
+		/* 84 Action22 <- <{ p.AddLabel(text) }> */
 		nil,
-		/* 56 Action7 <- <{ p.AddAlternate() }> */
+		/* 85 Action23 <- <{ p.AddLabeled() }> */
 		nil,
-		/* 57 Action8 <- <{ p.AddNil(); p.AddAlternate() }> */
+		/* 86 Action24 <- <{ p.AddQuery() }> */
 		nil,
-		/* 58 Action9 <- <{ p.AddNil() }> */
+		/* 87 Action25 <- <{ p.AddStar() }> */
 		nil,
-		/* 59 Action10 <- <{ p.AddSequence() }> */
+		/* 88 Action26 <- <{ p.AddPlus() }> */
 		nil,
-		/* 60 Action11 <- <{ p.AddPredicate(text) }> */
+		/* 89 Action27 <- <{ p.AddRecovery(text) }> */
 		nil,
-		/* 61 Action12 <- <{ p.AddStateChange(text) }> */
+		/* 90 Action28 <- <{ p.AddName(text) }> */
 		nil,
-		/* 62 Action13 <- <{ p.AddPeekFor() }> */
+		/* 91 Action29 <- <{ p.AddName(text) }> */
 		nil,
-		/* 63 Action14 <- <{ p.AddPeekNot() }> */
+		/* 92 Action30 <- <{ p.AddDot() }> */
 		nil,
-		/* 64 Action15 <- <{ p.AddQuery() }> */
+		/* 93 Action31 <- <{ p.AddAction(text) }> */
 		nil,
-		/* 65 Action16 <- <{ p.AddStar() }> */
+		/* 94 Action32 <- <{ p.AddCapture(text) }> */
 		nil,
-		/* 66 Action17 <- <{ p.AddPlus() }> */
+		/* 95 Action33 <- <{ p.AddCaptured() }> */
 		nil,
-		/* 67 Action18 <- <{ p.AddName(text) }> */
+		/* 96 Action34 <- <{ p.AddPush() }> */
 		nil,
-		/* 68 Action19 <- <{ p.AddDot() }> */
+		/* 97 Action35 <- <{ p.AddArgument() }> */
 		nil,
-		/* 69 Action20 <- <{ p.AddAction(text) }> */
+		/* 98 Action36 <- <{ p.AddSequence() }> */
 		nil,
-		/* 70 Action21 <- <{ p.AddPush() }> */
+		/* 99 Action37 <- <{ p.AddSequence() }> */
 		nil,
-		/* 71 Action22 <- <{ p.AddSequence() }> */
+		/* 100 Action38 <- <{ p.AddCaseInsensitive() }> */
 		nil,
-		/* 72 Action23 <- <{ p.AddSequence() }> */
+		/* 101 Action39 <- <{ p.AddPeekNot(); p.AddDot(); p.AddSequence() }> */
 		nil,
-		/* 73 Action24 <- <{ p.AddPeekNot(); p.AddDot(); p.AddSequence() }> */
+		/* 102 Action40 <- <{ p.AddPeekNot(); p.AddDot(); p.AddSequence() }> */
 		nil,
-		/* 74 Action25 <- <{ p.AddPeekNot(); p.AddDot(); p.AddSequence() }> */
+		/* 103 Action41 <- <{ p.AddAlternate() }> */
 		nil,
-		/* 75 Action26 <- <{ p.AddAlternate() }> */
+		/* 104 Action42 <- <{ p.AddAlternate() }> */
 		nil,
-		/* 76 Action27 <- <{ p.AddAlternate() }> */
+		/* 105 Action43 <- <{ p.AddRange() }> */
 		nil,
-		/* 77 Action28 <- <{ p.AddRange() }> */
+		/* 106 Action44 <- <{ p.AddDoubleRange() }> */
 		nil,
-		/* 78 Action29 <- <{ p.AddDoubleRange() }> */
+		/* 107 Action45 <- <{ p.AddProperty(text) }> */
 		nil,
-		/* 79 Action30 <- <{ p.AddCharacter(text) }> */
+		/* 108 Action46 <- <{ p.AddCharacter(text) }> */
 		nil,
-		/* 80 Action31 <- <{ p.AddDoubleCharacter(text) }> */
+		/* 109 Action47 <- <{ p.AddCharacter(text); p.AddCaseInsensitive() }> */
 		nil,
-		/* 81 Action32 <- <{ p.AddCharacter(text) }> */
+		/* 110 Action48 <- <{ p.AddCharacter("\a") }> */
 		nil,
-		/* 82 Action33 <- <{ p.AddCharacter("\a") }> */
+		/* 111 Action49 <- <{ p.AddCharacter("\b") }> */
 		nil,
-		/* 83 Action34 <- <{ p.AddCharacter("\b") }> */
+		/* 112 Action50 <- <{ p.AddCharacter("\x1B") }> */
 		nil,
-		/* 84 Action35 <- <{ p.AddCharacter("\x1B") }> */
+		/* 113 Action51 <- <{ p.AddCharacter("\f") }> */
 		nil,
-		/* 85 Action36 <- <{ p.AddCharacter("\f") }> */
+		/* 114 Action52 <- <{ p.AddCharacter("\n") }> */
 		nil,
-		/* 86 Action37 <- <{ p.AddCharacter("\n") }> */
+		/* 115 Action53 <- <{ p.AddCharacter("\r") }> */
 		nil,
-		/* 87 Action38 <- <{ p.AddCharacter("\r") }> */
+		/* 116 Action54 <- <{ p.AddCharacter("\t") }> */
 		nil,
-		/* 88 Action39 <- <{ p.AddCharacter("\t") }> */
+		/* 117 Action55 <- <{ p.AddCharacter("\v") }> */
 		nil,
-		/* 89 Action40 <- <{ p.AddCharacter("\v") }> */
+		/* 118 Action56 <- <{ p.AddCharacter("'") }> */
 		nil,
-		/* 90 Action41 <- <{ p.AddCharacter("'") }> */
+		/* 119 Action57 <- <{ p.AddCharacter("\"") }> */
 		nil,
-		/* 91 Action42 <- <{ p.AddCharacter("\"") }> */
+		/* 120 Action58 <- <{ p.AddCharacter("[") }> */
 		nil,
-		/* 92 Action43 <- <{ p.AddCharacter("[") }> */
+		/* 121 Action59 <- <{ p.AddCharacter("]") }> */
 		nil,
-		/* 93 Action44 <- <{ p.AddCharacter("]") }> */
+		/* 122 Action60 <- <{ p.AddCharacter("-") }> */
 		nil,
-		/* 94 Action45 <- <{ p.AddCharacter("-") }> */
+		/* 123 Action61 <- <{ p.AddHexaCharacter(text) }> */
 		nil,
-		/* 95 Action46 <- <{ p.AddHexaCharacter(text) }> */
+		/* 124 Action62 <- <{ p.AddHexaCharacter(text) }> */
 		nil,
-		/* 96 Action47 <- <{ p.AddOctalCharacter(text) }> */
+		/* 125 Action63 <- <{ p.AddOctalCharacter(text) }> */
 		nil,
-		/* 97 Action48 <- <{ p.AddOctalCharacter(text) }> */
+		/* 126 Action64 <- <{ p.AddOctalCharacter(text) }> */
 		nil,
-		/* 98 Action49 <- <{ p.AddCharacter("\\") }> */
+		/* 127 Action65 <- <{ p.AddCharacter("\\") }> */
 		nil,
-		/* 99 Action50 <- <{ p.AddSpace(text) }> */
+		/* 128 Action66 <- <{ p.AddSpace(text) }> */
 		nil,
-		/* 100 Action51 <- <{ p.AddComment(text) }> */
+		/* 129 Action67 <- <{ p.AddComment(text) }> */
 		nil,
 	}
+	limitDepth()
+	profileRules()
 	p.rules = _rules
 	return nil
 }
@@ -2,7 +2,13 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	"github.com/pointlander/peg/tree"
@@ -89,29 +95,32 @@ func TestSame(t *testing.T) {
 }
 
 func TestStrict(t *testing.T) {
-	tt := []string{
+	tt := []struct {
+		noast  bool
+		buffer string
+	}{
 		// rule used but not defined
-		`
+		{false, `
 package main
 type test Peg {}
 Begin <- begin !.
-`,
+`},
 		// rule defined but not used
-		`
+		{false, `
 package main
 type test Peg {}
 Begin <- .
 unused <- 'unused'
-`,
-		// left recursive rule
-		`package main
+`},
+		// left recursive rule without AST support
+		{true, `package main
 type test Peg {}
 Begin <- Begin 'x'
-`,
+`},
 	}
 
-	for i, buffer := range tt {
-		p := &Peg[uint32]{Tree: tree.New(false, false, false), Buffer: buffer}
+	for i, tc := range tt {
+		p := &Peg[uint32]{Tree: tree.New(false, false, tc.noast), Buffer: tc.buffer}
 		_ = p.Init(Size[uint32](1 << 15))
 		if err := p.Parse(); err != nil {
 			t.Fatal(err)
@@ -132,6 +141,423 @@ Begin <- Begin 'x'
 	}
 }
 
+func TestLeftRecursion(t *testing.T) {
+	buffer := `package main
+type test Peg {}
+Begin <- Begin 'x' / 'x'
+`
+	p := &Peg[uint32]{Tree: tree.New(false, false, false), Buffer: buffer}
+	_ = p.Init(Size[uint32](1 << 15))
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	p.Execute()
+
+	p.Strict = true
+	if err := p.Compile("", []string{"peg"}, &bytes.Buffer{}); err != nil {
+		t.Fatalf("unexpected error (%v)", err)
+	}
+	if !p.HasLeftRecursion {
+		t.Fatal("left recursion was not detected")
+	}
+}
+
+// compileRules compiles rules, the rules of a grammar of package main, with
+// the options of tree, and returns the code generated.
+func compileRules(t *testing.T, tree *tree.Tree, rules string) (string, error) {
+	t.Helper()
+	p := &Peg[uint32]{Tree: tree, Buffer: "package main\ntype test Peg {}\n" + rules}
+	_ = p.Init(Size[uint32](1 << 15))
+	if err := p.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	p.Execute()
+
+	out := &bytes.Buffer{}
+	err := p.Compile("", []string{"peg"}, out)
+	return out.String(), err
+}
+
+// compiled reports whether the grammar compiled without error when expected
+// is empty, and fails t unless err is nil or contains expected as it should.
+func compiled(t *testing.T, err error, expected string) bool {
+	t.Helper()
+	if expected == "" {
+		if err != nil {
+			t.Fatalf("unexpected error (%v)", err)
+		}
+		return true
+	}
+	if err == nil || !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected an error containing %q, got %v", expected, err)
+	}
+	return false
+}
+
+// TestTemplates checks the errors of templates, which grammars/template
+// checks the parsers of.
+func TestTemplates(t *testing.T) {
+	for _, test := range []struct {
+		grammar string
+		err     string
+	}{
+		{grammar: "Begin <- List('a', ',') !.\nList(E, Sep) <- E (Sep E)*\n"},
+		{"Begin <- List('a')\nList(E, Sep) <- E (Sep E)*\n", "takes 2 arguments but is called with 1"},
+		{"Begin <- Item('a', 'b')\nItem <- 'b'\n", "rule 'Item' is not a template"},
+		{"Begin <- Nest('a')\nNest(E) <- E / Nest(('x' E))\n", "expands more than 64 levels deep"},
+	} {
+		strict := tree.New(false, false, false)
+		strict.Strict = true
+		out, err := compileRules(t, strict, test.grammar)
+		if compiled(t, err, test.err) && !strings.Contains(out, `"List('a', ',')"`) {
+			t.Fatal("expected the instance of List to keep its title")
+		}
+	}
+}
+
+// TestValues checks the errors of semantic values, which grammars/values
+// checks the parsers of.
+func TestValues(t *testing.T) {
+	for _, test := range []struct {
+		grammar string
+		noast   bool
+		err     string
+	}{
+		{grammar: "Begin <- s:Sum !. { p.sum = s }\nSum <int> <- l:'1' { return len(l) }\n"},
+		{grammar: "Begin <- List('a') { p.items = len(text) }\nList(E) <[]string> <- e:E { return []string{e} }\n"},
+		{grammar: "Begin <- s:Sum\nSum <int> <- '1' { return 1 }\n", noast: true, err: "can't be used with -noast"},
+		{grammar: "Begin <- x:Sum / x:'1'\nSum <int> <- '1' { return 1 }\n", err: "label 'x' of rule 'Begin' is both int and string"},
+		{grammar: "Begin <- text:'1'\n", err: "label 'text' of rule 'Begin' hides a variable"},
+	} {
+		out, err := compileRules(t, tree.New(false, false, test.noast), test.grammar)
+		if compiled(t, err, test.err) && !strings.Contains(out, "pegValue") {
+			t.Fatal("expected frames holding the values")
+		}
+	}
+}
+
+// TestNamedCaptures checks the errors of named captures, which
+// grammars/captures checks the parsers of.
+func TestNamedCaptures(t *testing.T) {
+	for _, test := range []struct {
+		grammar string
+		noast   bool
+		err     string
+	}{
+		{grammar: "Begin <- <key: [a-z]+> '=' <val: [0-9]+> { p.set(key, val) }\n"},
+		{grammar: "Begin <- <key: [a-z]+>\n", noast: true, err: "can't be used with -noast"},
+		{grammar: "Begin <- <begin: [a-z]+>\n", err: "capture 'begin' hides a variable"},
+	} {
+		out, err := compileRules(t, tree.New(false, false, test.noast), test.grammar)
+		if compiled(t, err, test.err) && (!strings.Contains(out, "<key: ") || !strings.Contains(out, "valBegin, valEnd = int(t.begin), int(t.end)")) {
+			t.Fatal("expected the captures to keep their names")
+		}
+	}
+}
+
+// TestPositionsInActions checks pos is only computed for the actions using
+// it, and grammars/positions what it holds.
+func TestPositionsInActions(t *testing.T) {
+	for _, test := range []struct {
+		grammar  string
+		noast    bool
+		tracked  bool
+		err      string
+		expected string
+	}{
+		{grammar: "Begin <- [ \\n]* <[a-z]+> { p.check(text, pos.Line, pos.Column) } !.\n", tracked: true, expected: "abc [2 3]\n"},
+		{grammar: "Begin <- [ \\n]* <[a-z]+> { p.check(text, pos.Line, pos.Column) } !.\n", noast: true, tracked: true, expected: "abc [2 3]\n"},
+		{grammar: "Begin <- [ \\n]* <[a-z]+> { p.check(text, begin) } !.\n", expected: "abc [4]\n"},
+		{grammar: "Begin <- <pos: [a-z]+>\n", err: "capture 'pos' hides a variable"},
+	} {
+		out, err := compileRules(t, tree.New(false, false, test.noast), test.grammar)
+		if !compiled(t, err, test.err) {
+			continue
+		}
+		if tracked := strings.Contains(out, "pos = p.Position("); tracked != test.tracked {
+			t.Fatalf("got pos tracked %v for %q, want %v", tracked, test.grammar, test.tracked)
+		}
+		if testing.Short() {
+			continue
+		}
+		/* without an AST, the actions are run as the parse goes */
+		execute := "\tp.Execute()\n"
+		if test.noast {
+			execute = ""
+		}
+		main := `func (p *test[_]) check(text string, at ...any) {
+	fmt.Println(text, at)
+}
+
+func main() {
+	p := &test[uint32]{Buffer: " \n  abc"}
+	if err := p.Init(); err != nil {
+		panic(err)
+	}
+	if err := p.Parse(); err != nil {
+		panic(err)
+	}
+` + execute + "}\n"
+		if output := runParser(t, out, main); output != test.expected {
+			t.Errorf("got %q from the actions of %q, want %q", output, test.grammar, test.expected)
+		}
+	}
+}
+
+// runParser builds the parser generated in package main with the code of
+// main, and returns what it writes when run.
+func runParser(t *testing.T, parser, main string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"go.mod":    "module test\n\ngo 1.25\n",
+		"parser.go": parser,
+		"main.go":   "package main\n\nimport \"fmt\"\n\n" + main,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command("go", "run", ".")
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("%v\n%s", err, output)
+	}
+	return string(output)
+}
+
+func TestTrace(t *testing.T) {
+	for _, trace := range []bool{false, true} {
+		p := &Peg[uint32]{Tree: tree.New(true, true, false), Buffer: "package main\ntype test Peg {}\nBegin <- 'a' / 'b' Begin\n"}
+		_ = p.Init(Size[uint32](1 << 15))
+		if err := p.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		p.Execute()
+
+		p.Trace = trace
+		out := &bytes.Buffer{}
+		if err := p.Compile("", []string{"peg"}, out); err != nil {
+			t.Fatalf("unexpected error (%v)", err)
+		}
+		if traced := strings.Contains(out.String(), "trace"); traced != trace {
+			t.Fatalf("got tracing code %v, want %v", traced, trace)
+		}
+	}
+}
+
+// TestMemoAnnotations checks which rules are memoized, and grammars/memo
+// that they are parsed once at a position.
+func TestMemoAnnotations(t *testing.T) {
+	for _, test := range []struct {
+		grammar  string
+		inline   bool
+		memoized int
+		err      string
+	}{
+		/* Begin is the first rule and A is referred to twice, while B is
+		   only parsed once at a position by the memoized Begin */
+		{grammar: "Begin <- A ' ' A ' ' B\nA <- 'a'\nB <- 'b'\n", memoized: 2},
+		/* but not if it is reached at different distances from Begin */
+		{grammar: "Begin <- A sp A sp B\nA <- 'a'\n@nomemo sp <- ' '*\nB <- 'b'\n", memoized: 3},
+		{grammar: "S <- X !. / 'a' X 'd' !.\nX <- 'a'? B 'c'\nB <- 'b'\n", memoized: 3},
+		{grammar: "Begin <- A sp A sp B\nA <- 'a'\n@nomemo sp <- ' '*\n@memo B <- 'b'\n", memoized: 3},
+		{grammar: "Begin <- A sp A sp B\nA <- 'a'\n@nomemo sp <- ' '*\n@memo B <- 'b'\n", inline: true, memoized: 3},
+		{grammar: "Begin <- B* !.\nB <- 'b'\n", memoized: 2},
+		{grammar: "Begin <- B !.\n@nomemo B <- C\nC <- 'c'\n", memoized: 1},
+		{grammar: "Begin <- B 'x' / B 'y'\n@nomemo B <- C\nC <- 'c'\n", memoized: 2},
+		{grammar: "Begin <- A\n@fast A <- 'a'\n", err: "unknown annotation '@fast' on rule 'A'"},
+		{grammar: "Begin <- A\n@memo @nomemo A <- 'a'\n", err: "rule 'A' can't be both @memo and @nomemo"},
+		{grammar: "Begin <- E\n@nomemo E <- E '+' 'a' / 'a'\n", err: "rule 'E' is left recursive, so it can't be @nomemo"},
+	} {
+		out, err := compileRules(t, tree.New(test.inline, false, false), test.grammar)
+		if !compiled(t, err, test.err) {
+			continue
+		}
+		if memoized := strings.Count(out, "if memoized, ok := memoization"); memoized != test.memoized {
+			t.Errorf("got %d rules memoized for %q, want %d", memoized, test.grammar, test.memoized)
+		}
+	}
+}
+
+// TestUnicodeProperties checks the code generated for the classes of
+// Unicode properties, which grammars/properties checks the parsers of.
+func TestUnicodeProperties(t *testing.T) {
+	for _, test := range []struct {
+		grammar string
+		err     string
+	}{
+		{grammar: "Begin <- [\\p{L}_] [\\p{Greek}\\P{Nd}]* !.\n"},
+		{"Begin <- [\\p{Letter}]\n", "unknown Unicode category or script in '\\p{Letter}'"},
+	} {
+		out, err := compileRules(t, tree.New(false, true, false), test.grammar)
+		if !compiled(t, err, test.err) {
+			continue
+		}
+		for _, code := range []string{"unicode.Is(unicode.L, ", "unicode.Is(unicode.Greek, ", "unicode.Is(unicode.Nd, "} {
+			if !strings.Contains(out, code) {
+				t.Errorf("expected the generated parser to contain %q", code)
+			}
+		}
+	}
+}
+
+// TestBytes checks the errors of -bytes, which grammars/binary checks the
+// parsers of.
+func TestBytes(t *testing.T) {
+	for _, test := range []struct {
+		grammar string
+		err     string
+	}{
+		{grammar: "Begin <- '\\xff' [\\x00-\\x7f] \"abc\" .* !.\n"},
+		{"Begin <- [\\p{L}]\n", "'\\p{L}' can't be used in a parser over bytes"},
+		{"Begin <- '中'\n", "'中' doesn't fit in a byte"},
+	} {
+		bytes := tree.New(false, true, false)
+		bytes.Bytes = true
+		out, err := compileRules(t, bytes, test.grammar)
+		if compiled(t, err, test.err) && !strings.Contains(out, "if peek() != 'ÿ' {") {
+			t.Error("expected the generated parser to match bytes")
+		}
+	}
+}
+
+func TestInclude(t *testing.T) {
+	for _, test := range []struct {
+		files map[string]string
+		err   string
+	}{
+		{files: map[string]string{
+			"a.peg": "include \"b.peg\"\ninclude c \"c.peg\"\nBegin <- B c.C !.\n",
+			"b.peg": "include \"d.peg\"\nB <- D\n",
+			"c.peg": "include \"d.peg\"\nC <- D\n",
+			"d.peg": "D <- 'd'\n",
+		}},
+		{map[string]string{
+			"a.peg": "include \"b.peg\"\nBegin <- B !.\nB <- 'a'\n",
+			"b.peg": "B <- 'b'\n",
+		}, "b.peg: rule 'B' is already defined in the including grammar"},
+		{map[string]string{
+			"a.peg": "include \"b.peg\"\ninclude \"c.peg\"\nBegin <- B !.\n",
+			"b.peg": "B <- 'b'\n",
+			"c.peg": "B <- 'c'\n",
+		}, "c.peg: rule 'B' is already defined in " + filepath.Join("%v", "b.peg")},
+		{map[string]string{
+			"a.peg": "include \"b.peg\"\nBegin <- B !.\n",
+			"b.peg": "include \"a.peg\"\nB <- 'b'\n",
+		}, "include cycle"},
+		{map[string]string{
+			"a.peg": "include \"b.peg\"\nBegin <- B !.\n",
+			"b.peg": "package b\ntype B Peg {}\nB <- 'b'\n",
+		}, "included grammars can't declare a package"},
+	} {
+		dir := t.TempDir()
+		test.files["a.peg"] = "package main\ntype test Peg {}\n" + test.files["a.peg"]
+		for name, content := range test.files {
+			if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		path := filepath.Join(dir, "a.peg")
+		p := &Peg[uint32]{Tree: tree.New(false, false, false), Buffer: test.files["a.peg"]}
+		_ = p.Init(Size[uint32](1 << 15))
+		if err := p.Parse(); err != nil {
+			t.Fatal(err)
+		}
+		p.Execute()
+
+		err := include(p.Tree, path, nil)
+		if test.err == "" {
+			if err != nil {
+				t.Fatalf("unexpected error (%v)", err)
+			}
+			p.Strict = true
+			if err := p.Compile("", []string{"peg"}, &bytes.Buffer{}); err != nil {
+				t.Fatalf("unexpected error (%v)", err)
+			}
+			continue
+		}
+		if expected := strings.ReplaceAll(test.err, "%v", dir); err == nil || !strings.Contains(err.Error(), expected) {
+			t.Fatalf("expected an error containing %q, got %v", expected, err)
+		}
+	}
+}
+
+func TestParseErrorExpected(t *testing.T) {
+	buffer := `package main
+type test Peg {}
+A <- B # é
+B <- [a-z]
+3
+`
+	p := &Peg[uint32]{Tree: tree.New(false, false, false), Buffer: buffer}
+	_ = p.Init(Size[uint32](1 << 15))
+	err := p.Parse()
+	if err == nil {
+		t.Fatal("expected a parse error")
+	}
+	expected := "expected Plus, Star, Question, Caret, Prefix, Slash, Annotation, Identifier or end of input at line 5 col 1"
+	if !strings.Contains(err.Error(), expected) {
+		t.Fatalf("expected %q in %q", expected, err.Error())
+	}
+
+	var parseError *ParseError
+	if !errors.As(err, &parseError) {
+		t.Fatalf("expected a *ParseError, got %T", err)
+	}
+	position := Position{Offset: 53, Rune: 52, Line: 5, Column: 1}
+	if parseError.Position != position {
+		t.Fatalf("expected position %+v, got %+v", position, parseError.Position)
+	}
+	if parseError.Rule != "EndOfLine" || parseError.Text != "\n" || parseError.Snippet != "3" {
+		t.Fatalf("unexpected rule %q, text %q or snippet %q", parseError.Rule, parseError.Text, parseError.Snippet)
+	}
+	if !slices.Contains(parseError.Expected, "Identifier") {
+		t.Fatalf("expected Identifier in %v", parseError.Expected)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	buffer, err := os.ReadFile("peg.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Peg[uint32]{Tree: tree.New(false, false, false), Buffer: string(buffer)}
+	if err := p.Init(MaxSteps[uint32](1000)); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Parse(); !errors.Is(err, ErrMaxSteps) {
+		t.Fatalf("expected ErrMaxSteps, got %v", err)
+	}
+	p.maxSteps = 0
+	if err := p.Parse(); err != nil {
+		t.Fatalf("the parse after running out of steps failed: %v", err)
+	}
+	if err := p.Init(MaxSteps[uint32](-1)); err == nil {
+		t.Fatal("expected an error for a negative number of steps")
+	}
+}
+
+func TestParseContext(t *testing.T) {
+	buffer, err := os.ReadFile("peg.peg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Peg[uint32]{Tree: tree.New(false, false, false), Buffer: string(buffer)}
+	if err := p.Init(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.ParseContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if err := p.ParseContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestCJKCharacter(t *testing.T) {
 	buffer := `
 package main
@@ -316,10 +742,20 @@ func BenchmarkInitOnly(b *testing.B) {
 }
 
 func BenchmarkParse(b *testing.B) {
+	benchmarkParse(b)
+}
+
+// BenchmarkParseSparseMemo is BenchmarkParse with the memoized results
+// kept in a map instead of the dense table.
+func BenchmarkParseSparseMemo(b *testing.B) {
+	benchmarkParse(b, SparseMemo[uint32]())
+}
+
+func benchmarkParse(b *testing.B, options ...func(*Peg[uint32]) error) {
 	pegs := make([]*Peg[uint32], len(pegFileContents))
 	for i, content := range pegFileContents {
 		p := &Peg[uint32]{Tree: tree.New(true, true, false), Buffer: content}
-		_ = p.Init(Size[uint32](1 << 15))
+		_ = p.Init(append(options, Size[uint32](1<<15))...)
 		pegs[i] = p
 	}
 
@@ -353,11 +789,39 @@ func BenchmarkParseAndReset(b *testing.B) {
 	}
 }
 
+func BenchmarkAST(b *testing.B) {
+	pegs := make([]*Peg[uint32], len(pegFileContents))
+	for i, content := range pegFileContents {
+		p := &Peg[uint32]{Tree: tree.New(true, true, false), Buffer: content}
+		_ = p.Init(Size[uint32](1 << 15))
+		if err := p.Parse(); err != nil {
+			b.Fatal(err)
+		}
+		pegs[i] = p
+	}
+
+	for b.Loop() {
+		for _, peg := range pegs {
+			if peg.AST() == nil {
+				b.Fatal("no syntax tree")
+			}
+		}
+	}
+}
+
 func BenchmarkInitAndParse(b *testing.B) {
+	benchmarkInitAndParse(b)
+}
+
+func BenchmarkInitAndParseSparseMemo(b *testing.B) {
+	benchmarkInitAndParse(b, SparseMemo[uint32]())
+}
+
+func benchmarkInitAndParse(b *testing.B, options ...func(*Peg[uint32]) error) {
 	for b.Loop() {
 		for _, peg := range pegFileContents {
 			p := &Peg[uint32]{Tree: tree.New(true, true, false), Buffer: peg}
-			_ = p.Init(Size[uint32](1 << 15))
+			_ = p.Init(append(options, Size[uint32](1<<15))...)
 			if err := p.Parse(); err != nil {
 				b.Fatal(err)
 			}
@@ -7,12 +7,14 @@ package tree
 import (
 	"bytes"
 	_ "embed"
+	"errors"
 	"fmt"
 	"go/parser"
 	"go/printer"
 	"go/token"
 	"io"
 	"iter"
+	"maps"
 	"math"
 	"os"
 	"slices"
@@ -21,6 +23,7 @@ import (
 	"sync"
 	"text/template"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/pointlander/peg/set"
 )
@@ -39,6 +42,11 @@ var templateFuncs = template.FuncMap{
 	},
 }
 
+// exported returns name with its first letter in upper case.
+func exported(name string) string {
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
 type Type uint8
 
 const (
@@ -49,6 +57,8 @@ const (
 	TypeCharacter
 	TypeRange
 	TypeString
+	TypeCaseInsensitive
+	TypeProperty
 	TypePredicate
 	TypeStateChange
 	TypeCommit
@@ -69,6 +79,8 @@ const (
 	TypePeg
 	TypePush
 	TypeImplicitPush
+	TypeRecovery
+	TypeLabel
 	TypeNil
 	TypeLast
 )
@@ -82,6 +94,8 @@ var TypeMap = [...]string{
 	"TypeCharacter",
 	"TypeRange",
 	"TypeString",
+	"TypeCaseInsensitive",
+	"TypeProperty",
 	"TypePredicate",
 	"TypeStateChange",
 	"TypeCommit",
@@ -102,6 +116,8 @@ var TypeMap = [...]string{
 	"TypePeg",
 	"TypePush",
 	"TypeImplicitPush",
+	"TypeRecovery",
+	"TypeLabel",
 	"TypeNil",
 	"TypeLast",
 }
@@ -122,7 +138,8 @@ func (t Type) GetType() Type {
 type node struct {
 	Type
 	string
-	id int
+	title string
+	id    int
 
 	front  *node
 	back   *node
@@ -139,6 +156,15 @@ func (n *node) String() string {
 	return n.string
 }
 
+// Title returns the name of a rule as it reads in the grammar. It only
+// differs from String for instances of rule templates.
+func (n *node) Title() string {
+	if n.title != "" {
+		return n.title
+	}
+	return n.string
+}
+
 func (n *node) Escaped() string {
 	return escape(n.string)
 }
@@ -214,7 +240,16 @@ func (n *node) Len() (c int) {
 }
 
 func (n *node) Copy() *node {
-	return &node{Type: n.Type, string: n.string, id: n.id, front: n.front, back: n.back, length: n.length}
+	return &node{Type: n.Type, string: n.string, title: n.title, id: n.id, front: n.front, back: n.back, length: n.length}
+}
+
+// clone copies n and all of its children.
+func (n *node) clone() *node {
+	c := &node{Type: n.Type, string: n.string, title: n.title, id: n.id}
+	for element := range n.Iterator() {
+		c.PushBack(element.clone())
+	}
+	return c
 }
 
 func (n *node) Iterator() iter.Seq[*node] {
@@ -288,6 +323,10 @@ func (n *node) checkAlwaysSucceedsRecursion(t *Tree, visited map[*node]bool) boo
 			if element.checkAlwaysSucceedsRecursion(t, visited) {
 				return true
 			}
+			if element.ownsCommit() {
+				// A failure after the cut fails the whole choice
+				return false
+			}
 		}
 		return false
 	case TypeSequence:
@@ -302,46 +341,133 @@ func (n *node) checkAlwaysSucceedsRecursion(t *Tree, visited map[*node]bool) boo
 			return child.checkAlwaysSucceedsRecursion(t, visited)
 		}
 		return false
-	case TypeAction, TypeQuery, TypeStar, TypeNil:
+	case TypeRecovery:
+		return n.Front().checkAlwaysSucceedsRecursion(t, visited) ||
+			n.Front().Next().checkAlwaysSucceedsRecursion(t, visited)
+	case TypeQuery, TypeStar:
+		return !n.Front().ownsCommit()
+	case TypeAction, TypeCommit, TypeNil:
 		return true
 	default:
 		return false
 	}
 }
 
+// ownsCommit reports whether n contains a cut that commits the choice n is
+// an alternative or repetition of. Cuts nested inside other choices or rules
+// belong to those instead.
+func (n *node) ownsCommit() bool {
+	switch n.GetType() {
+	case TypeCommit:
+		return true
+	case TypeSequence, TypePush, TypeImplicitPush:
+		for element := range n.Iterator() {
+			if element.ownsCommit() {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Include is a grammar file whose rules are merged into the grammar that
+// includes it. The rules of an include with a namespace are referred to as
+// Namespace.Rule.
+type Include struct {
+	Path      string
+	Namespace string
+}
+
 // Tree is a tree data structure into which a PEG can be parsed.
 type Tree struct {
 	Rules      map[string]*node
 	rulesCount map[string]uint
 	node
+	leftRecursion        []int
 	inline, _switch, Ast bool
 	Strict               bool
+	Bytes                bool
+	Stream               bool
+	TypedAst             bool
+	Trace                bool
 	werr                 error
+	namespace            string
+	origins              map[string]string
+
+	Includes []Include
+
+	Generator          string
+	RuleNames          []*node
+	Comments           string
+	PackageName        string
+	Imports            []string
+	EndSymbol          rune
+	PegRuleType        string
+	StructName         string
+	StructVariables    string
+	RulesCount         int
+	HasActions         bool
+	Actions            []*node
+	HasPush            bool
+	HasPosition        bool
+	HasCommit          bool
+	HasDot             bool
+	HasCharacter       bool
+	HasString          bool
+	HasCaseInsensitive bool
+	HasProperty        bool
+	HasRange           bool
+	HasLeftRecursion   bool
+	HasRecovery        bool
+	TypedNodes         []TypedNode
+	VisitedRules       []VisitedRule
+	ValueRules         []ValueRule
+	Captures           []string
+
+	resultTypes map[string]string
+	annotations map[string][]string
+	pending     []string
+	memoize     map[string]bool
+}
+
+// ValueRule is a rule with a result type or labels. Execute keeps a frame
+// with the labels and the value of each of its matches being run in a stack.
+type ValueRule struct {
+	Rule  string
+	Type  string
+	Frame string
+}
+
+// VisitedRule is a rule the generated Visitor has the methods Enter and
+// Exit followed by Method for.
+type VisitedRule struct {
+	Rule   string
+	Method string
+}
 
-	Generator       string
-	RuleNames       []*node
-	Comments        string
-	PackageName     string
-	Imports         []string
-	EndSymbol       rune
-	PegRuleType     string
-	StructName      string
-	StructVariables string
-	RulesCount      int
-	HasActions      bool
-	Actions         []*node
-	HasPush         bool
-	HasCommit       bool
-	HasDot          bool
-	HasCharacter    bool
-	HasString       bool
-	HasRange        bool
+// TypedNode is the Go struct generated for a rule with -typed-ast.
+type TypedNode struct {
+	Name   string
+	Rule   string
+	Title  string
+	Fields []TypedField
+}
+
+// TypedField is a field of a TypedNode, holding the matches of a sub-rule,
+// or the text of a capture if Capture is set. Many fields hold a slice.
+type TypedField struct {
+	Name    string
+	Rule    string
+	Type    string
+	Many    bool
+	Capture bool
 }
 
 func New(inline, _switch, noast bool) *Tree {
 	return &Tree{
 		Rules:      make(map[string]*node),
 		rulesCount: make(map[string]uint),
+		origins:    make(map[string]string),
 		inline:     inline,
 		_switch:    _switch,
 		Ast:        !noast,
@@ -351,6 +477,43 @@ func New(inline, _switch, noast bool) *Tree {
 func (t *Tree) AddRule(name string) {
 	t.PushFront(&node{Type: TypeRule, string: name, id: t.RulesCount})
 	t.RulesCount++
+	if len(t.pending) > 0 {
+		if t.annotations == nil {
+			t.annotations = make(map[string][]string)
+		}
+		t.annotations[name], t.pending = t.pending, nil
+	}
+}
+
+// AddParameter adds a parameter to the rule being defined, making it a rule
+// template. The parameters come before the expression in the rule's children.
+func (t *Tree) AddParameter(text string) {
+	t.Front().PushBack(&node{Type: TypeName, string: text})
+}
+
+// AddAnnotation adds an annotation, such as @memo, to the rule defined next.
+func (t *Tree) AddAnnotation(text string) {
+	t.pending = append(t.pending, text)
+}
+
+// AddResultType sets the Go type of the values of the rule being defined.
+func (t *Tree) AddResultType(text string) {
+	if t.resultTypes == nil {
+		t.resultTypes = make(map[string]string)
+	}
+	t.resultTypes[t.Front().String()] = strings.TrimSpace(text)
+}
+
+// AddLabel starts a label, which binds the value of the expression after it
+// to a variable of the actions of the rule.
+func (t *Tree) AddLabel(text string) {
+	t.PushFront(&node{Type: TypeLabel, string: text})
+}
+
+// AddLabeled adds the expression below it to the label below that.
+func (t *Tree) AddLabeled() {
+	expression := t.PopFront()
+	t.Front().PushBack(expression)
 }
 
 func (t *Tree) AddExpression() {
@@ -360,15 +523,45 @@ func (t *Tree) AddExpression() {
 	t.PushBack(rule)
 }
 
+// AddName adds a reference to a rule. References to rules of namespaced
+// includes are written Namespace.Rule, which is turned into an identifier.
 func (t *Tree) AddName(text string) {
-	t.PushFront(&node{Type: TypeName, string: text})
+	t.PushFront(&node{Type: TypeName, string: strings.ReplaceAll(text, ".", "_"), title: text})
 }
 
-func (t *Tree) AddDot() { t.PushFront(&node{Type: TypeDot, string: "."}) }
+// AddArgument adds an argument to the call of a rule template below it.
+func (t *Tree) AddArgument() {
+	argument := t.PopFront()
+	t.Front().PushBack(argument)
+}
+
+func (t *Tree) AddDot()    { t.PushFront(&node{Type: TypeDot, string: "."}) }
+func (t *Tree) AddCommit() { t.PushFront(&node{Type: TypeCommit, string: "~"}) }
 func (t *Tree) AddCharacter(text string) {
 	t.PushFront(&node{Type: TypeCharacter, string: text})
 }
 
+// AddCaseInsensitive turns the character or sequence of characters below it
+// into a single match that ignores case.
+func (t *Tree) AddCaseInsensitive() {
+	n := t.PopFront()
+	text := n.String()
+	if n.GetType() == TypeSequence {
+		var characters strings.Builder
+		for character := range n.Iterator() {
+			characters.WriteString(character.String())
+		}
+		text = characters.String()
+	}
+	t.PushFront(&node{Type: TypeCaseInsensitive, string: text})
+}
+
+// AddProperty adds a \\p{Name} or \\P{Name} class matching one character
+// that is, or isn't, in the Unicode category or script Name.
+func (t *Tree) AddProperty(text string) {
+	t.PushFront(&node{Type: TypeProperty, string: text})
+}
+
 func (t *Tree) AddDoubleCharacter(text string) {
 	t.PushFront(&node{Type: TypeCharacter, string: strings.ToLower(text)})
 	t.PushFront(&node{Type: TypeCharacter, string: strings.ToUpper(text)})
@@ -384,15 +577,20 @@ func (t *Tree) AddOctalCharacter(text string) {
 	octal, _ := strconv.ParseInt(text, 8, 8)
 	t.PushFront(&node{Type: TypeCharacter, string: string(rune(octal))})
 }
-func (t *Tree) AddPredicate(text string)   { t.PushFront(&node{Type: TypePredicate, string: text}) }
-func (t *Tree) AddStateChange(text string) { t.PushFront(&node{Type: TypeStateChange, string: text}) }
-func (t *Tree) AddNil()                    { t.PushFront(&node{Type: TypeNil, string: "<nil>"}) }
-func (t *Tree) AddAction(text string)      { t.PushFront(&node{Type: TypeAction, string: text}) }
-func (t *Tree) AddPackage(text string)     { t.PushBack(&node{Type: TypePackage, string: text}) }
-func (t *Tree) AddSpace(text string)       { t.PushBack(&node{Type: TypeSpace, string: text}) }
-func (t *Tree) AddComment(text string)     { t.PushBack(&node{Type: TypeComment, string: text}) }
-func (t *Tree) AddImport(text string)      { t.PushBack(&node{Type: TypeImport, string: text}) }
-func (t *Tree) AddImportAlias(text string) { t.PushBack(&node{Type: TypeImport, string: "=" + text}) }
+func (t *Tree) AddPredicate(text string)        { t.PushFront(&node{Type: TypePredicate, string: text}) }
+func (t *Tree) AddStateChange(text string)      { t.PushFront(&node{Type: TypeStateChange, string: text}) }
+func (t *Tree) AddNil()                         { t.PushFront(&node{Type: TypeNil, string: "<nil>"}) }
+func (t *Tree) AddAction(text string)           { t.PushFront(&node{Type: TypeAction, string: text}) }
+func (t *Tree) AddPackage(text string)          { t.PushBack(&node{Type: TypePackage, string: text}) }
+func (t *Tree) AddSpace(text string)            { t.PushBack(&node{Type: TypeSpace, string: text}) }
+func (t *Tree) AddComment(text string)          { t.PushBack(&node{Type: TypeComment, string: text}) }
+func (t *Tree) AddImport(text string)           { t.PushBack(&node{Type: TypeImport, string: text}) }
+func (t *Tree) AddImportAlias(text string)      { t.PushBack(&node{Type: TypeImport, string: "=" + text}) }
+func (t *Tree) AddIncludeNamespace(text string) { t.namespace = text }
+func (t *Tree) AddInclude(text string) {
+	t.Includes = append(t.Includes, Include{Path: text, Namespace: t.namespace})
+	t.namespace = ""
+}
 func (t *Tree) AddState(text string) {
 	peg := t.PopFront()
 	peg.PushBack(&node{Type: TypeState, string: text})
@@ -430,91 +628,1093 @@ func (t *Tree) AddDoubleRange() {
 	t.AddAlternate()
 }
 
-func (t *Tree) addFix(fixType Type) {
-	n := &node{Type: fixType}
-	n.PushBack(t.PopFront())
-	t.PushFront(n)
+func (t *Tree) addFix(fixType Type) {
+	n := &node{Type: fixType}
+	n.PushBack(t.PopFront())
+	t.PushFront(n)
+}
+func (t *Tree) AddPeekFor() { t.addFix(TypePeekFor) }
+func (t *Tree) AddPeekNot() { t.addFix(TypePeekNot) }
+func (t *Tree) AddQuery()   { t.addFix(TypeQuery) }
+func (t *Tree) AddStar()    { t.addFix(TypeStar) }
+func (t *Tree) AddPlus()    { t.addFix(TypePlus) }
+func (t *Tree) AddPush()    { t.addFix(TypePush) }
+
+// AddCapture starts a named capture, whose text is kept in a variable of the
+// actions of its own.
+func (t *Tree) AddCapture(text string) {
+	t.PushFront(&node{Type: TypePush, string: text})
+}
+
+// AddCaptured adds the expression below it to the named capture below that.
+func (t *Tree) AddCaptured() {
+	expression := t.PopFront()
+	t.Front().PushBack(expression)
+}
+
+func (t *Tree) AddRecovery(text string) {
+	n := &node{Type: TypeRecovery}
+	n.PushBack(t.PopFront())
+	n.PushBack(&node{Type: TypeName, string: text})
+	t.PushFront(n)
+}
+
+func (t *Tree) AddPeg(text string) { t.PushFront(&node{Type: TypePeg, string: text}) }
+
+// property returns the Unicode category or script named by a property class,
+// or nil if there is none, and whether the class is negated.
+func property(n *node) (name string, table *unicode.RangeTable, negated bool) {
+	s := n.String()
+	name, negated = s[len(`\p{`):len(s)-len(`}`)], s[1] == 'P'
+	if table = unicode.Categories[name]; table == nil {
+		table = unicode.Scripts[name]
+	}
+	return name, table, negated
+}
+
+// checkCharacters reports property classes in n that name no Unicode
+// category or script. A parser over bytes can't match property classes or
+// characters above \xff at all.
+func (t *Tree) checkCharacters(n *node) error {
+	switch n.GetType() {
+	case TypeProperty:
+		if t.Bytes {
+			return fmt.Errorf("'%v' can't be used in a parser over bytes", n)
+		}
+		if _, table, _ := property(n); table == nil {
+			return fmt.Errorf("unknown Unicode category or script in '%v'", n)
+		}
+	case TypeCharacter, TypeCaseInsensitive:
+		if !t.Bytes {
+			break
+		}
+		for _, c := range n.String() {
+			if c > 0xff {
+				return fmt.Errorf("'%v' doesn't fit in a byte", escape(string(c)))
+			}
+		}
+	}
+	for element := range n.Iterator() {
+		if element.GetType() == TypeRule {
+			continue
+		}
+		if err := t.checkCharacters(element); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func escape(c string) string {
+	switch c {
+	case "'":
+		return "\\'"
+	case "\"":
+		return "\""
+	default:
+		c = strconv.Quote(c)
+		return c[1 : len(c)-1]
+	}
+}
+
+// expectation describes what n matches first, for use in parse errors. It
+// returns an empty string if there is no simple description.
+func (t *Tree) expectation(n *node) string {
+	switch n.GetType() {
+	case TypeName:
+		if rule := t.Rules[n.String()]; rule == nil || rule.CheckAlwaysSucceeds(t) {
+			return ""
+		}
+		return n.Title()
+	case TypeDot:
+		return "any character"
+	case TypeCharacter, TypeString:
+		return "'" + escape(n.String()) + "'"
+	case TypeCaseInsensitive:
+		return strconv.Quote(n.String())
+	case TypeRange:
+		element := n.Front()
+		return fmt.Sprintf("[%v-%v]", escape(element.String()), escape(element.Next().String()))
+	case TypeProperty:
+		return "[" + n.String() + "]"
+	case TypeSequence:
+		for element := range n.Iterator() {
+			switch element.GetType() {
+			case TypePeekFor, TypePeekNot, TypePredicate, TypeStateChange, TypeAction:
+				continue
+			}
+			if element.CheckAlwaysSucceeds(t) {
+				continue
+			}
+			return t.expectation(element)
+		}
+	case TypePush, TypeImplicitPush, TypePlus:
+		return t.expectation(n.Front())
+	}
+	return ""
+}
+
+// isFiller reports whether rule only repeats something zero or more times,
+// like the rules skipping white space and comments usually do.
+func isFiller(rule *node) bool {
+	expression := rule.Front()
+	if expression.GetType() == TypeImplicitPush {
+		expression = expression.Front()
+	}
+	return expression.GetType() == TypeStar
+}
+
+// describeClass describes the characters of a switch case as a character
+// class.
+func describeClass(class *node) string {
+	var characters []rune
+	for character := range class.Iterator() {
+		if character.GetType() == TypeCharacter {
+			characters = append(characters, []rune(character.String())[0])
+		}
+	}
+	if len(characters) == 1 {
+		return "'" + escape(string(characters[0])) + "'"
+	}
+	slices.Sort(characters)
+	description := "["
+	for i := 0; i < len(characters); {
+		j := i
+		for j+1 < len(characters) && characters[j+1] == characters[j]+1 {
+			j++
+		}
+		description += escape(string(characters[i]))
+		if j > i {
+			description += "-" + escape(string(characters[j]))
+		}
+		i = j + 1
+	}
+	return description + "]"
+}
+
+func (t *Tree) countRules(n *node, ruleReached []bool) {
+	switch n.GetType() {
+	case TypeRule:
+		name, id := n.String(), n.GetID()
+		if count, ok := t.rulesCount[name]; ok {
+			t.rulesCount[name] = count + 1
+		} else {
+			t.rulesCount[name] = 1
+		}
+		if ruleReached[id] {
+			return
+		}
+		ruleReached[id] = true
+		t.countRules(n.Front(), ruleReached)
+	case TypeName:
+		t.countRules(t.Rules[n.String()], ruleReached)
+	case TypeImplicitPush, TypePush:
+		t.countRules(n.Front(), ruleReached)
+	case TypeAlternate, TypeUnorderedAlternate, TypeSequence,
+		TypePeekFor, TypePeekNot, TypeQuery, TypeStar, TypePlus, TypeRecovery:
+		for element := range n.Iterator() {
+			t.countRules(element, ruleReached)
+		}
+	}
+}
+
+func (t *Tree) checkRecursion(n *node, path []int) bool {
+	switch n.GetType() {
+	case TypeRule:
+		id := n.GetID()
+		if i := slices.Index(path, id); i >= 0 {
+			t.addLeftRecursion(n, path[i:])
+			return false
+		}
+		return t.checkRecursion(n.Front(), append(path, id))
+	case TypeAlternate:
+		for element := range n.Iterator() {
+			if !t.checkRecursion(element, path) {
+				return false
+			}
+		}
+		return true
+	case TypeSequence:
+		return slices.ContainsFunc(slices.Collect(n.Iterator()), func(n *node) bool {
+			return t.checkRecursion(n, path)
+		})
+	case TypeName:
+		return t.checkRecursion(t.Rules[n.String()], path)
+	case TypePlus, TypePush, TypeImplicitPush:
+		return t.checkRecursion(n.Front(), path)
+	case TypeRecovery:
+		consumes := t.checkRecursion(n.Front(), path)
+		return t.checkRecursion(n.Front().Next(), path) && consumes
+	case TypeCharacter, TypeString, TypeCaseInsensitive:
+		return len(n.String()) > 0
+	case TypeDot, TypeRange, TypeProperty:
+		return true
+	}
+	return false
+}
+
+// addLeftRecursion records that the rules in cycle can call themselves
+// without consuming input. Rules sharing a cycle are grouped together so the
+// generated parser can grow their seeds jointly.
+func (t *Tree) addLeftRecursion(n *node, cycle []int) {
+	if !t.Ast {
+		t.warn(fmt.Errorf("possible infinite left recursion in rule '%v'", n))
+		return
+	}
+	find := func(id int) int {
+		for t.leftRecursion[id] != id {
+			id = t.leftRecursion[id]
+		}
+		return id
+	}
+	for _, id := range cycle {
+		if t.leftRecursion[id] < 0 {
+			t.leftRecursion[id] = id
+		}
+	}
+	root := find(cycle[0])
+	for _, id := range cycle[1:] {
+		t.leftRecursion[find(id)] = root
+	}
+}
+
+// leftRecursive reports whether the rule with the given id is left recursive
+// and returns the other rules it is mutually left recursive with.
+func (t *Tree) leftRecursive(id int) (recursive bool, involved []int) {
+	if t.leftRecursion[id] < 0 {
+		return false, nil
+	}
+	find := func(id int) int {
+		for t.leftRecursion[id] != id {
+			id = t.leftRecursion[id]
+		}
+		return id
+	}
+	root := find(id)
+	for other, parent := range t.leftRecursion {
+		if other != id && parent >= 0 && find(other) == root {
+			involved = append(involved, other)
+		}
+	}
+	return true, involved
+}
+
+// checkAnnotations checks the annotations of the rules, keeping whether
+// they are marked @memo or @nomemo in t.memoize.
+func (t *Tree) checkAnnotations() error {
+	t.memoize = make(map[string]bool)
+	for _, rule := range slices.Sorted(maps.Keys(t.annotations)) {
+		for _, annotation := range t.annotations[rule] {
+			var memoize bool
+			switch annotation {
+			case "memo":
+				memoize = true
+			case "nomemo":
+			default:
+				return fmt.Errorf("unknown annotation '@%v' on rule '%v'", annotation, rule)
+			}
+			if other, ok := t.memoize[rule]; ok && other != memoize {
+				return fmt.Errorf("rule '%v' can't be both @memo and @nomemo", rule)
+			}
+			t.memoize[rule] = memoize
+		}
+	}
+	return nil
+}
+
+// inlined reports whether -inline copies the rule with that name into the
+// only rule referring to it. A rule marked @memo keeps its own function.
+func (t *Tree) inlined(name string) bool {
+	memoize, ok := t.memoize[name]
+	return t.inline && t.rulesCount[name] == 1 && !(ok && memoize)
+}
+
+// memoizedRules finds the rules whose results are memoized. Those are the
+// rules marked @memo, and those not marked @nomemo that could be parsed more
+// than once at the same position. A rule is only parsed once at a position
+// if it is referred to once, outside any repetition and after only elements
+// of a fixed width, by a rule that is itself memoized or only parsed once at
+// a position: each time it is parsed, its caller was parsed at the same
+// distance before it. Left recursive rules are always memoized, since that
+// is how they grow.
+func (t *Tree) memoizedRules() (map[string]bool, error) {
+	// a reference is fixed if it is always reached at the same offset from
+	// where its caller started
+	type reference struct {
+		caller          string
+		repeated, fixed bool
+	}
+	references := make(map[string][]reference)
+	var refer func(n *node, caller string, repeated, fixed bool)
+	refer = func(n *node, caller string, repeated, fixed bool) {
+		switch n.GetType() {
+		case TypeRule:
+		case TypeName:
+			references[n.String()] = append(references[n.String()], reference{caller, repeated, fixed})
+		case TypeStar, TypePlus:
+			for element := range n.Iterator() {
+				refer(element, caller, true, fixed)
+			}
+		case TypeSequence:
+			for element := range n.Iterator() {
+				refer(element, caller, repeated, fixed)
+				if fixed {
+					_, fixed = t.width(element, make(map[string]bool))
+				}
+			}
+		default:
+			for element := range n.Iterator() {
+				refer(element, caller, repeated, fixed)
+			}
+		}
+	}
+	recursive := make(map[string]bool)
+	for n := range t.Iterator() {
+		if n.GetType() != TypeRule {
+			continue
+		}
+		recursive[n.String()], _ = t.leftRecursive(n.GetID())
+		if memoize, ok := t.memoize[n.String()]; ok && !memoize && recursive[n.String()] {
+			return nil, fmt.Errorf("rule '%v' is left recursive, so it can't be @nomemo", n)
+		}
+		if _, ok := t.rulesCount[n.String()]; ok {
+			refer(n.Front(), n.String(), false, true)
+		}
+	}
+
+	const (
+		unknown = iota
+		deciding
+		decided
+	)
+	state, once := make(map[string]int), make(map[string]bool)
+	var parsedOnce func(name string) bool
+	parsedOnce = func(name string) bool {
+		switch state[name] {
+		case deciding:
+			return false
+		case decided:
+			return once[name]
+		}
+		state[name] = deciding
+		if r := references[name]; len(r) == 1 && !r[0].repeated && r[0].fixed && !recursive[name] && state[r[0].caller] != deciding {
+			caller := r[0].caller
+			memoize, ok := t.memoize[caller]
+			once[name] = parsedOnce(caller) || !recursive[caller] && !t.inlined(caller) && (!ok || memoize)
+		}
+		state[name] = decided
+		return once[name]
+	}
+
+	memoized := make(map[string]bool)
+	for n := range t.Iterator() {
+		if n.GetType() != TypeRule {
+			continue
+		}
+		name := n.String()
+		memoize, ok := t.memoize[name]
+		memoized[name] = t.Ast && (recursive[name] || ok && memoize || !ok && !parsedOnce(name))
+	}
+	return memoized, nil
+}
+
+// width returns how much input n matches, and whether that is always the
+// same, following the rules n refers to unless they are in visiting.
+func (t *Tree) width(n *node, visiting map[string]bool) (int, bool) {
+	switch n.GetType() {
+	case TypeCharacter, TypeRange, TypeProperty, TypeDot:
+		return 1, true
+	case TypeString, TypeCaseInsensitive:
+		return utf8.RuneCountInString(n.String()), true
+	case TypePredicate, TypeStateChange, TypeAction, TypeCommit, TypeNil, TypePeekFor, TypePeekNot:
+		return 0, true
+	case TypePush, TypeImplicitPush, TypeLabel:
+		return t.width(n.Front(), visiting)
+	case TypeName:
+		rule := t.Rules[n.String()]
+		if rule == nil || visiting[n.String()] {
+			return 0, false
+		}
+		visiting[n.String()] = true
+		defer delete(visiting, n.String())
+		return t.width(rule.Front(), visiting)
+	case TypeSequence:
+		sum := 0
+		for element := range n.Iterator() {
+			width, fixed := t.width(element, visiting)
+			if !fixed {
+				return 0, false
+			}
+			sum += width
+		}
+		return sum, true
+	case TypeAlternate:
+		width := -1
+		for element := range n.Iterator() {
+			w, fixed := t.width(element, visiting)
+			if !fixed || width >= 0 && w != width {
+				return 0, false
+			}
+			width = w
+		}
+		return width, width >= 0
+	}
+	return 0, false
+}
+
+// callsRules reports whether the code compiled for n calls the function of
+// a rule, following the rules inlined into it unless they are in visiting.
+func (t *Tree) callsRules(n *node, visiting map[string]bool) bool {
+	if n.GetType() == TypeName {
+		name := n.String()
+		if !t.inlined(name) || visiting[name] {
+			return true
+		}
+		visiting[name] = true
+		return t.callsRules(t.Rules[name].Front(), visiting)
+	}
+	for element := range n.Iterator() {
+		if t.callsRules(element, visiting) {
+			return true
+		}
+	}
+	return false
+}
+
+// occurrences counts how many times each sub-rule and capture of expression
+// n matches in one match of n, where 2 stands for more than once, and lists
+// them in the order they appear. The rules matched inside a capture count
+// as matched by n.
+func occurrences(n *node, counts map[string]int, order *[]string) {
+	count := func(name string) {
+		if !slices.Contains(*order, name) {
+			*order = append(*order, name)
+		}
+		counts[name] = min(counts[name]+1, 2)
+	}
+	switch n.GetType() {
+	case TypeName:
+		count(n.String())
+	case TypePush:
+		count(captureRule(n.String()))
+		occurrences(n.Front(), counts, order)
+	case TypeImplicitPush, TypeQuery:
+		occurrences(n.Front(), counts, order)
+	case TypeSequence:
+		for element := range n.Iterator() {
+			occurrences(element, counts, order)
+		}
+	case TypeAlternate, TypeUnorderedAlternate, TypeRecovery:
+		/* only one alternative matches */
+		for element := range n.Iterator() {
+			if n.GetType() == TypeUnorderedAlternate {
+				element = element.Front().Next()
+			}
+			alternative := make(map[string]int)
+			occurrences(element, alternative, order)
+			for name, c := range alternative {
+				counts[name] = max(counts[name], c)
+			}
+		}
+	case TypeStar, TypePlus:
+		repeated := make(map[string]int)
+		occurrences(n.Front(), repeated, order)
+		for name := range repeated {
+			counts[name] = 2
+		}
+	}
+}
+
+// typedNodes lists the structs generated for the rules with -typed-ast.
+func (t *Tree) typedNodes() []TypedNode {
+	typed := make(map[string]bool)
+	for element := range t.Iterator() {
+		if element.GetType() != TypeRule || element.String() == "PegText" {
+			continue
+		}
+		if expression := element.Front(); expression.GetType() == TypeNil || expression.Front().GetType() == TypeNil {
+			continue
+		}
+		if _, ok := t.rulesCount[element.String()]; ok {
+			typed[element.String()] = true
+		}
+	}
+	names := t.goNames()
+	var nodes []TypedNode
+	for element := range t.Iterator() {
+		if element.GetType() != TypeRule || !typed[element.String()] {
+			continue
+		}
+		counts, order := make(map[string]int), []string(nil)
+		occurrences(element.Front(), counts, &order)
+		text := "Text"
+		if counts["text"] > 0 || counts["Text"] > 0 {
+			text = "PegText"
+		}
+		node := TypedNode{Name: names[element.String()] + "Node", Rule: element.String(), Title: element.Title()}
+		for _, name := range order {
+			switch {
+			case name == "PegText":
+				node.Fields = append(node.Fields, TypedField{Name: text, Rule: name, Many: counts[name] > 1, Capture: true})
+			case strings.HasPrefix(name, "PegText_"):
+				node.Fields = append(node.Fields, TypedField{Name: exported(strings.TrimPrefix(name, "PegText_")), Rule: name, Many: counts[name] > 1, Capture: true})
+			case typed[name]:
+				node.Fields = append(node.Fields, TypedField{Name: names[name], Rule: name, Type: names[name] + "Node", Many: counts[name] > 1})
+			}
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes
+}
+
+// goNames maps the rules to the names the Go code generated for them is
+// named after: the rule with its first letter in upper case, unless that is
+// the name of another rule too, like for 'value' and 'Value', in which case
+// the rule as it is written.
+func (t *Tree) goNames() map[string]string {
+	names, count := make(map[string]string, len(t.RuleNames)), make(map[string]int)
+	for _, rule := range t.RuleNames {
+		count[exported(rule.String())]++
+	}
+	for _, rule := range t.RuleNames {
+		names[rule.String()] = exported(rule.String())
+		if count[names[rule.String()]] > 1 {
+			names[rule.String()] = rule.String()
+		}
+	}
+	return names
+}
+
+// visitedRules lists the rules the generated Visitor has methods for, which
+// are all of them but the actions, as those never match any text. The
+// methods are named after the rules as by goNames.
+func (t *Tree) visitedRules() []VisitedRule {
+	actions := make(map[string]bool, len(t.Actions))
+	for i := range t.Actions {
+		actions[fmt.Sprintf("Action%v", i)] = true
+	}
+	names := t.goNames()
+	var rules []VisitedRule
+	for _, rule := range t.RuleNames {
+		if !actions[rule.String()] {
+			rules = append(rules, VisitedRule{Rule: rule.String(), Method: names[rule.String()]})
+		}
+	}
+	return rules
+}
+
+// printRule writes n to w in grammar syntax.
+func (t *Tree) printRule(w io.Writer, n *node) {
+	_print := func(format string, a ...any) { _, _ = fmt.Fprintf(w, format, a...) }
+	switch n.GetType() {
+	case TypeRule:
+		_print("%v <- ", n.Title())
+		t.printRule(w, n.Front())
+	case TypeDot:
+		_print(".")
+	case TypeName:
+		_print("%v", n.Title())
+	case TypeCharacter:
+		_print("'%v'", escape(n.String()))
+	case TypeString:
+		s := escape(n.String())
+		_print("'%v'", s[1:len(s)-1])
+	case TypeCaseInsensitive:
+		_print("%v", strconv.Quote(n.String()))
+	case TypeRange:
+		element := n.Front()
+		lower := element
+		element = element.Next()
+		upper := element
+		_print("[%v-%v]", escape(lower.String()), escape(upper.String()))
+	case TypeProperty:
+		_print("[%v]", n)
+	case TypePredicate:
+		_print("&{%v}", n)
+	case TypeStateChange:
+		_print("!{%v}", n)
+	case TypeAction:
+		_print("{%v}", n)
+	case TypeLabel:
+		_print("%v:", n)
+		t.printRule(w, n.Front())
+	case TypeCommit:
+		_print("~")
+	case TypeAlternate:
+		_print("(")
+		elements := slices.Collect(n.Iterator())
+		t.printRule(w, elements[0])
+		for _, element := range elements[1:] {
+			_print(" / ")
+			t.printRule(w, element)
+		}
+		_print(")")
+	case TypeUnorderedAlternate:
+		_print("(")
+		elements := slices.Collect(n.Iterator())
+		t.printRule(w, elements[0])
+		for _, element := range elements[1:] {
+			_print(" | ")
+			t.printRule(w, element)
+		}
+		_print(")")
+	case TypeSequence:
+		_print("(")
+		elements := slices.Collect(n.Iterator())
+		t.printRule(w, elements[0])
+		for _, element := range elements[1:] {
+			_print(" ")
+			t.printRule(w, element)
+		}
+		_print(")")
+	case TypePeekFor:
+		_print("&")
+		t.printRule(w, n.Front())
+	case TypePeekNot:
+		_print("!")
+		t.printRule(w, n.Front())
+	case TypeQuery:
+		t.printRule(w, n.Front())
+		_print("?")
+	case TypeStar:
+		t.printRule(w, n.Front())
+		_print("*")
+	case TypePlus:
+		t.printRule(w, n.Front())
+		_print("+")
+	case TypePush, TypeImplicitPush:
+		_print("<")
+		if n.String() != "" {
+			_print("%v: ", n)
+		}
+		t.printRule(w, n.Front())
+		_print(">")
+	case TypeRecovery:
+		t.printRule(w, n.Front())
+		_print("^%v", n.Front().Next())
+	case TypeComment:
+	case TypeNil:
+	default:
+		t.warn(fmt.Errorf("illegal node type: %v", n.GetType()))
+	}
+}
+
+// Merge adds the rules of module, parsed from the file of include, to the
+// grammar. The rules of a namespaced include, and the references to them
+// from within module, are renamed to Namespace.Rule. Rules that reach the
+// grammar twice from the same file are only added once; any other rule
+// defined twice is an error.
+func (t *Tree) Merge(module *Tree, include Include) error {
+	local := make(map[string]bool)
+	for n := range module.Iterator() {
+		switch n.GetType() {
+		case TypePackage, TypePeg:
+			return fmt.Errorf("%v: included grammars can't declare a package or parser type", include.Path)
+		case TypeRule:
+			local[n.String()] = true
+		}
+	}
+	defined := make(map[string]bool)
+	for n := range t.Iterator() {
+		if n.GetType() == TypeRule {
+			defined[n.String()] = true
+		}
+	}
+
+	qualify := func(n *node) {
+		if include.Namespace != "" && local[n.String()] {
+			n.string, n.title = include.Namespace+"_"+n.String(), include.Namespace+"."+n.Title()
+		}
+	}
+	var parameters []*node
+	var rename func(n *node)
+	rename = func(n *node) {
+		if n.GetType() == TypeName && !slices.ContainsFunc(parameters, func(parameter *node) bool {
+			return parameter.String() == n.String()
+		}) {
+			qualify(n)
+		}
+		for element := range n.Iterator() {
+			rename(element)
+		}
+	}
+
+	for _, n := range slices.Collect(module.Iterator()) {
+		if n.GetType() != TypeRule {
+			continue
+		}
+		origin, ok := module.origins[n.String()]
+		if !ok {
+			origin = include.Path
+		}
+		elements := slices.Collect(n.Iterator())
+		parameters = elements[:len(elements)-1]
+		rename(elements[len(elements)-1])
+		qualify(n)
+
+		if defined[n.String()] {
+			if t.origins[n.String()] == origin {
+				continue
+			}
+			where := "the including grammar"
+			if other, ok := t.origins[n.String()]; ok {
+				where = other
+			}
+			return fmt.Errorf("%v: rule '%v' is already defined in %v", origin, n.Title(), where)
+		}
+		defined[n.String()] = true
+		t.origins[n.String()] = origin
+		n.next = nil
+		n.SetID(t.RulesCount)
+		t.RulesCount++
+		t.PushBack(n)
+	}
+	return nil
+}
+
+// maxTemplateDepth limits how deeply rule templates may instantiate each
+// other, so templates calling themselves with growing arguments are reported
+// instead of expanding forever.
+const maxTemplateDepth = 64
+
+// uncall turns the calls with one argument of rules that aren't templates
+// below n back into what they were written as before templates: a reference
+// followed by an expression in parentheses. The prefix of the call, like
+// '!' or a label, applies to the reference and its suffix, like '*', to the
+// expression.
+func uncall(n *node, templates map[string]*node) {
+	children := slices.Collect(n.Iterator())
+	n.Init()
+	for _, child := range children {
+		child.next = nil
+		var wrappers []*node
+		call := child
+		for {
+			switch call.GetType() {
+			case TypePeekFor, TypePeekNot, TypeLabel, TypeQuery, TypeStar, TypePlus, TypeRecovery:
+				wrappers = append(wrappers, call)
+				call = call.Front()
+				continue
+			}
+			break
+		}
+		if _, ok := templates[call.String()]; ok || call.GetType() != TypeName || call.Len() != 1 {
+			uncall(child, templates)
+			n.PushBack(child)
+			continue
+		}
+		reference := &node{Type: TypeName, string: call.string, title: call.title}
+		expression := call.Front()
+		expression.next = nil
+		uncall(expression, templates)
+		for _, wrapper := range slices.Backward(wrappers) {
+			rest := wrapper.Front().Next()
+			wrapper.Init()
+			switch wrapper.GetType() {
+			case TypePeekFor, TypePeekNot, TypeLabel:
+				reference.next = nil
+				wrapper.PushBack(reference)
+				reference = wrapper
+			default:
+				expression.next = nil
+				wrapper.PushBack(expression)
+				expression = wrapper
+			}
+			if rest != nil {
+				wrapper.PushBack(rest)
+			}
+		}
+		reference.next, expression.next = nil, nil
+		if n.GetType() == TypeSequence {
+			n.PushBack(reference)
+			n.PushBack(expression)
+			continue
+		}
+		sequence := &node{Type: TypeSequence}
+		sequence.PushBack(reference)
+		sequence.PushBack(expression)
+		n.PushBack(sequence)
+	}
+}
+
+// expandTemplates replaces the calls of rule templates with references to
+// instances of them, one for each distinct list of arguments. An instance is
+// a copy of the template with its parameters substituted, named after the
+// template and titled after the call.
+func (t *Tree) expandTemplates() error {
+	rules := slices.Collect(t.Iterator())
+	templates := make(map[string]*node)
+	names := make(map[string]bool)
+	for _, n := range rules {
+		if n.GetType() != TypeRule {
+			continue
+		}
+		names[n.String()] = true
+		if n.Len() > 1 {
+			templates[n.String()] = n
+		}
+	}
+	for _, n := range rules {
+		if n.GetType() == TypeRule {
+			uncall(n, templates)
+		}
+	}
+	t.Init()
+	for _, n := range rules {
+		n.next = nil
+		if _, ok := templates[n.String()]; !ok || n.GetType() != TypeRule {
+			t.PushBack(n)
+		}
+	}
+
+	var substitute func(n *node, parameters, arguments []*node)
+	substitute = func(n *node, parameters, arguments []*node) {
+		for element := range n.Iterator() {
+			if element.GetType() == TypeName && element.Len() == 0 {
+				i := slices.IndexFunc(parameters, func(parameter *node) bool {
+					return parameter.String() == element.String()
+				})
+				if i >= 0 {
+					argument := arguments[i].clone()
+					element.Type, element.string, element.title = argument.Type, argument.string, argument.title
+					element.front, element.back, element.length = argument.front, argument.back, argument.length
+					continue
+				}
+			}
+			substitute(element, parameters, arguments)
+		}
+	}
+
+	instances := make(map[string]*node)
+	used := make(map[string]bool)
+	var expand func(n *node, depth int) error
+	expand = func(n *node, depth int) error {
+		for element := range n.Iterator() {
+			if element.GetType() == TypeRule {
+				// the rule an implicit push refers to
+				continue
+			}
+			if err := expand(element, depth); err != nil {
+				return err
+			}
+		}
+		if n.GetType() != TypeName || n.Len() == 0 {
+			return nil
+		}
+		template, ok := templates[n.String()]
+		if !ok {
+			return fmt.Errorf("rule '%v' is not a template but is called with arguments", n)
+		}
+		used[n.String()] = true
+		parameters := slices.Collect(template.Iterator())
+		parameters, body := parameters[:len(parameters)-1], parameters[len(parameters)-1]
+		arguments := slices.Collect(n.Iterator())
+		if len(arguments) != len(parameters) {
+			return fmt.Errorf("rule template '%v' takes %d arguments but is called with %d",
+				n, len(parameters), len(arguments))
+		}
+		var title strings.Builder
+		title.WriteString(n.Title() + "(")
+		for i, argument := range arguments {
+			if i > 0 {
+				title.WriteString(", ")
+			}
+			t.printRule(&title, argument)
+		}
+		title.WriteString(")")
+
+		instance, ok := instances[title.String()]
+		if !ok {
+			if depth == maxTemplateDepth {
+				return fmt.Errorf("rule template '%v' expands more than %d levels deep", n, maxTemplateDepth)
+			}
+			name := n.String()
+			for i := 1; names[name]; i++ {
+				name = n.String() + strconv.Itoa(i)
+			}
+			names[name] = true
+			instance = &node{Type: TypeRule, string: name, title: title.String()}
+			instances[instance.title] = instance
+			if result, ok := t.resultTypes[n.String()]; ok {
+				t.resultTypes[name] = result
+			}
+			if annotations, ok := t.annotations[n.String()]; ok {
+				t.annotations[name] = annotations
+			}
+			expression := body.clone()
+			substitute(expression, parameters, arguments)
+			instance.PushBack(expression)
+			t.PushBack(instance)
+			if err := expand(expression, depth+1); err != nil {
+				return err
+			}
+		}
+		n.Init()
+		n.string, n.title = instance.string, instance.title
+		return nil
+	}
+	for _, n := range slices.Collect(t.Iterator()) {
+		if n.GetType() == TypeRule {
+			if err := expand(n, 0); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(templates)) {
+		if !used[name] {
+			t.warn(fmt.Errorf("rule template '%v' defined but not used", name))
+		}
+	}
+
+	t.RulesCount = 0
+	for n := range t.Iterator() {
+		if n.GetType() == TypeRule {
+			n.SetID(t.RulesCount)
+			t.RulesCount++
+		}
+	}
+	return nil
+}
+
+// hidesActionVariable reports whether a label or a capture named name would
+// hide a variable the actions rely on.
+func hidesActionVariable(name string) bool {
+	switch name {
+	case "p", "t", "buffer", "_buffer", "text", "begin", "end", "pos":
+		return true
+	}
+	return false
 }
-func (t *Tree) AddPeekFor() { t.addFix(TypePeekFor) }
-func (t *Tree) AddPeekNot() { t.addFix(TypePeekNot) }
-func (t *Tree) AddQuery()   { t.addFix(TypeQuery) }
-func (t *Tree) AddStar()    { t.addFix(TypeStar) }
-func (t *Tree) AddPlus()    { t.addFix(TypePlus) }
-func (t *Tree) AddPush()    { t.addFix(TypePush) }
 
-func (t *Tree) AddPeg(text string) { t.PushFront(&node{Type: TypePeg, string: text}) }
+// mentions reports whether the Go code uses the identifier name.
+func mentions(code, name string) bool {
+	notIdentifier := func(r rune) bool { return r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) }
+	return slices.Contains(strings.FieldsFunc(code, notIdentifier), name)
+}
 
-func escape(c string) string {
-	switch c {
-	case "'":
-		return "\\'"
-	case "\"":
-		return "\""
-	default:
-		c = strconv.Quote(c)
-		return c[1 : len(c)-1]
+// captureRule returns the name of the rule of the tokens of a capture, which
+// is PegText for the captures without a name.
+func captureRule(name string) string {
+	if name == "" {
+		return "PegText"
 	}
+	return "PegText_" + name
 }
 
-func (t *Tree) countRules(n *node, ruleReached []bool) {
-	switch n.GetType() {
-	case TypeRule:
-		name, id := n.String(), n.GetID()
-		if count, ok := t.rulesCount[name]; ok {
-			t.rulesCount[name] = count + 1
-		} else {
-			t.rulesCount[name] = 1
-		}
-		if ruleReached[id] {
-			return
+// addValues turns the labels and the actions of the rules with a result type
+// or labels into actions running in a frame of the rule's match, which holds
+// the labels and the value. The frame is pushed by an action starting the
+// rule and popped by the token of the rule, as the tokens come in the order
+// the matches end.
+func (t *Tree) addValues() error {
+	var labels func(rule, n *node, types map[string]string, order *[]string) error
+	labels = func(rule, n *node, types map[string]string, order *[]string) error {
+		if n.GetType() == TypeLabel {
+			name, labelType := n.String(), "string"
+			if expression := n.Front(); expression.GetType() == TypeName && t.resultTypes[expression.String()] != "" {
+				labelType = t.resultTypes[expression.String()]
+			}
+			if hidesActionVariable(name) {
+				return fmt.Errorf("label '%v' of rule '%v' hides a variable of the actions", name, rule.Title())
+			}
+			if previous, ok := types[name]; ok && previous != labelType {
+				return fmt.Errorf("label '%v' of rule '%v' is both %v and %v", name, rule.Title(), previous, labelType)
+			} else if !ok {
+				types[name] = labelType
+				*order = append(*order, name)
+			}
 		}
-		ruleReached[id] = true
-		t.countRules(n.Front(), ruleReached)
-	case TypeName:
-		t.countRules(t.Rules[n.String()], ruleReached)
-	case TypeImplicitPush, TypePush:
-		t.countRules(n.Front(), ruleReached)
-	case TypeAlternate, TypeUnorderedAlternate, TypeSequence,
-		TypePeekFor, TypePeekNot, TypeQuery, TypeStar, TypePlus:
 		for element := range n.Iterator() {
-			t.countRules(element, ruleReached)
+			if element.GetType() == TypeRule {
+				continue
+			}
+			if err := labels(rule, element, types, order); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
-}
 
-func (t *Tree) checkRecursion(n *node, ruleReached []bool) bool {
-	switch n.GetType() {
-	case TypeRule:
-		id := n.GetID()
-		if ruleReached[id] {
-			t.warn(fmt.Errorf("possible infinite left recursion in rule '%v'", n))
-			return false
-		}
-		ruleReached[id] = true
-		consumes := t.checkRecursion(n.Front(), ruleReached)
-		ruleReached[id] = false
-		return consumes
-	case TypeAlternate:
+	var rewrite func(n *node, frames string, order []string, resultType string)
+	rewrite = func(n *node, frames string, order []string, resultType string) {
 		for element := range n.Iterator() {
-			if !t.checkRecursion(element, ruleReached) {
-				return false
+			if element.GetType() != TypeRule {
+				rewrite(element, frames, order, resultType)
 			}
 		}
-		return true
-	case TypeSequence:
-		return slices.ContainsFunc(slices.Collect(n.Iterator()), func(n *node) bool {
-			return t.checkRecursion(n, ruleReached)
-		})
-	case TypeName:
-		return t.checkRecursion(t.Rules[n.String()], ruleReached)
-	case TypePlus, TypePush, TypeImplicitPush:
-		return t.checkRecursion(n.Front(), ruleReached)
-	case TypeCharacter, TypeString:
-		return len(n.String()) > 0
-	case TypeDot, TypeRange:
-		return true
+		switch n.GetType() {
+		case TypeAction:
+			var code strings.Builder
+			fmt.Fprintf(&code, "pegFrame := &%v[len(%v)-1]", frames, frames)
+			fields := make([]string, len(order))
+			for i, name := range order {
+				fields[i] = "pegFrame." + name
+			}
+			if len(order) > 0 {
+				fmt.Fprintf(&code, "\n%v := %v", strings.Join(order, ", "), strings.Join(fields, ", "))
+			}
+			if resultType != "" {
+				fmt.Fprintf(&code, "\npegFrame.pegValue = func() %v {\n%v\n}()", resultType, n.String())
+			} else {
+				fmt.Fprintf(&code, "\n%v", n.String())
+			}
+			if len(order) > 0 {
+				fmt.Fprintf(&code, "\n%v = %v", strings.Join(fields, ", "), strings.Join(order, ", "))
+			}
+			n.SetString(code.String())
+		case TypeLabel:
+			name, expression := n.String(), n.Front()
+			value := "text"
+			if expression.GetType() == TypeName && t.resultTypes[expression.String()] != "" {
+				value = "value" + expression.String()
+			} else {
+				push := &node{Type: TypePush}
+				push.PushBack(expression)
+				expression = push
+			}
+			n.Init()
+			n.SetType(TypeSequence)
+			n.PushBack(expression)
+			n.PushBack(&node{Type: TypeAction, string: fmt.Sprintf("%v[len(%v)-1].%v = %v", frames, frames, name, value)})
+		}
 	}
-	return false
+
+	for rule := range t.Iterator() {
+		if _, ok := t.Rules[rule.String()]; ok || rule.GetType() != TypeRule {
+			// already compiled
+			continue
+		}
+		types, order := make(map[string]string), []string(nil)
+		if err := labels(rule, rule.Front(), types, &order); err != nil {
+			return err
+		}
+		resultType := t.resultTypes[rule.String()]
+		if resultType == "" && len(order) == 0 {
+			continue
+		}
+		if !t.Ast || t.Stream {
+			return errors.New("semantic values need the syntax tree, so they can't be used with -noast or -stream")
+		}
+		var frame strings.Builder
+		frame.WriteString("struct {")
+		if resultType != "" {
+			fmt.Fprintf(&frame, "\npegValue %v", resultType)
+		}
+		for _, name := range order {
+			fmt.Fprintf(&frame, "\n%v %v", name, types[name])
+		}
+		frame.WriteString("\n}")
+		frames := "frames" + rule.String()
+		expression := rule.Front()
+		rewrite(expression, frames, order, resultType)
+		sequence := &node{Type: TypeSequence}
+		sequence.PushBack(&node{Type: TypeAction, string: fmt.Sprintf("%v = append(%v, %v{})", frames, frames, frame.String())})
+		sequence.PushBack(expression)
+		rule.Init()
+		rule.PushBack(sequence)
+		t.ValueRules = append(t.ValueRules, ValueRule{Rule: rule.String(), Type: resultType, Frame: frame.String()})
+	}
+	return nil
 }
 
 func (t *Tree) warn(e error) {
@@ -569,9 +1769,12 @@ func (t *Tree) link(countsForRule *[TypeLast]uint, n *node, counts *[TypeLast]ui
 		}
 	case TypePush:
 		cp := rule.Copy()
-		name := "PegText"
+		name := captureRule(n.String())
 		cp.SetString(name)
 		if _, ok := t.Rules[name]; !ok {
+			if n.String() != "" {
+				t.Captures = append(t.Captures, n.String())
+			}
 			emptyRule := &node{Type: TypeRule, string: name, id: t.RulesCount}
 			emptyRule.PushBack(&node{Type: TypeNil, string: "<nil>"})
 			t.PushBack(emptyRule)
@@ -586,23 +1789,69 @@ func (t *Tree) link(countsForRule *[TypeLast]uint, n *node, counts *[TypeLast]ui
 	case TypeImplicitPush:
 		t.link(countsForRule, n.Front(), counts, countsByRule, rule)
 	case TypeRule, TypeAlternate, TypeUnorderedAlternate, TypeSequence,
-		TypePeekFor, TypePeekNot, TypeQuery, TypeStar, TypePlus:
+		TypePeekFor, TypePeekNot, TypeQuery, TypeStar, TypePlus, TypeRecovery:
 		for node := range n.Iterator() {
 			t.link(countsForRule, node, counts, countsByRule, rule)
 		}
 	}
 }
 
+// maxSwitchCase is the most characters -switch lists in one case of a
+// switch statement. Alternatives starting with more, like a Unicode letter
+// class, are tried in order instead.
+const maxSwitchCase = 1024
+
 func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 	t.AddImport("fmt")
+	t.AddImport("slices")
+	t.AddImport("strconv")
+	/* ParseError lists what was expected, and the limits of a parse
+	   have errors of their own */
+	t.AddImport("errors")
+	t.AddImport("strings")
+	/* ParseContext */
+	t.AddImport("context")
+	/* Position */
+	t.AddImport("unicode/utf8")
+	/* the Time of a RuleProfile */
+	t.AddImport("time")
 	if t.Ast {
 		t.AddImport("io")
+		t.AddImport("iter")
 		t.AddImport("os")
 		t.AddImport("bytes")
 	}
-	t.AddImport("slices")
-	t.AddImport("strconv")
+	if t.Trace {
+		t.AddImport("io")
+		t.AddImport("os")
+	}
+	if t.Stream {
+		t.AddImport("io")
+		if !t.Bytes {
+			t.AddImport("bufio")
+		}
+	}
+	if err := t.expandTemplates(); err != nil {
+		return err
+	}
+	if err := t.checkAnnotations(); err != nil {
+		return err
+	}
+	if err := t.addValues(); err != nil {
+		return err
+	}
+	for n := range t.Iterator() {
+		if n.GetType() != TypeRule {
+			continue
+		}
+		if err := t.checkCharacters(n.Front()); err != nil {
+			return err
+		}
+	}
 	t.EndSymbol = 0x110000
+	if t.Bytes {
+		t.EndSymbol = 0x100
+	}
 	t.RulesCount++
 
 	t.Generator = strings.Join(slices.Concat([]string{"peg"}, args[1:]), " ")
@@ -641,8 +1890,13 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			}
 		}
 	}
+	if t.PackageName == "" {
+		return errors.New("missing package declaration")
+	}
+
 	/* sort imports to satisfy gofmt */
 	slices.Sort(t.Imports)
+	t.Imports = slices.Compact(t.Imports)
 
 	/* second pass */
 	for _, n := range slices.Collect(t.Iterator()) {
@@ -674,17 +1928,25 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 		}
 	})
 
+	t.leftRecursion = make([]int, t.RulesCount)
+	for i := range t.leftRecursion {
+		t.leftRecursion[i] = -1
+	}
 	wg.Go(func() {
-		ruleReached := make([]bool, t.RulesCount)
 		for n := range t.Iterator() {
 			if n.GetType() == TypeRule {
-				t.checkRecursion(n, ruleReached)
+				t.checkRecursion(n, nil)
 			}
 		}
 	})
 
 	wg.Wait()
 
+	memoizing, err := t.memoizedRules()
+	if err != nil {
+		return err
+	}
+
 	if t._switch {
 		var optimizeAlternates func(node *node) (consumes bool, s *set.Set)
 		cache := make([]struct {
@@ -722,6 +1984,19 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			case TypeString, TypeCharacter:
 				consumes = true
 				s.Add([]rune(n.String())[0])
+			case TypeCaseInsensitive:
+				consumes = true
+				first := []rune(n.String())[0]
+				s.Add(first)
+				if t.Bytes && first >= utf8.RuneSelf {
+					/* only ASCII letters fold in a parser over bytes */
+					break
+				}
+				for c := unicode.SimpleFold(first); c != first; c = unicode.SimpleFold(c) {
+					if c < t.EndSymbol {
+						s.Add(c)
+					}
+				}
 			case TypeRange:
 				consumes = true
 				element := n.Front()
@@ -729,6 +2004,27 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 				element = element.Next()
 				upper := []rune(element.String())[0]
 				s.AddRange(lower, upper)
+			case TypeProperty:
+				consumes = true
+				_, table, negated := property(n)
+				addRange := func(lo, hi, stride rune) {
+					if stride == 1 {
+						s.AddRange(lo, hi)
+						return
+					}
+					for c := lo; c <= hi; c += stride {
+						s.Add(c)
+					}
+				}
+				for _, r := range table.R16 {
+					addRange(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+				}
+				for _, r := range table.R32 {
+					addRange(rune(r.Lo), rune(r.Hi), rune(r.Stride))
+				}
+				if negated {
+					s = s.Complement(t.EndSymbol - 1)
+				}
 			case TypeAlternate:
 				consumes = true
 				properties := make([]struct {
@@ -747,9 +2043,28 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 				if firstPass {
 					break
 				}
+				/* an alternative such as !. that doesn't look at the next
+				   symbol has no case of the switch to go in */
+				blind := false
+				for i, element := range n.Iterator2() {
+					blind = blind || properties[i].s.Len() == 0 && element.GetType() != TypeNil
+				}
+				if blind {
+					break
+				}
 
 				intersections := 2
+				for i := range properties {
+					/* too many characters to list in a switch case */
+					if properties[i].s.Len() > maxSwitchCase {
+						intersections++
+						properties[i].intersects = true
+					}
+				}
 				for ai, a := range properties[:len(properties)-1] {
+					if a.intersects {
+						continue
+					}
 					for _, b := range properties[ai+1:] {
 						if a.s.Intersects(b.s) {
 							intersections++
@@ -837,6 +2152,11 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 				_, s = optimizeAlternates(n.Front())
 			case TypePlus, TypePush, TypeImplicitPush:
 				consumes, s = optimizeAlternates(n.Front())
+			case TypeRecovery:
+				/* the recovery rule starts where the expression started */
+				consumes, s = optimizeAlternates(n.Front())
+				recovers, r := optimizeAlternates(n.Front().Next())
+				consumes, s = consumes && recovers, s.Union(r)
 			case TypeAction, TypeNil:
 				// empty
 			}
@@ -866,27 +2186,124 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 	_print := func(format string, a ...any) { _, _ = fmt.Fprintf(&buffer, format, a...) }
 	printSave := func(n uint) { _print("\n   position%d, tokenIndex%d := position, tokenIndex", n, n) }
 	printRestore := func(n uint) { _print("\n   position, tokenIndex = position%d, tokenIndex%d", n, n) }
+	printBacktrack := func(n uint) {
+		if t.Trace {
+			_print("\n   traceBacktrack(position%d)", n)
+		}
+	}
 	printMemoSave := func(rule int, n uint64, ret bool) {
-		_print("\n   memoize(%d, position%d, tokenIndex%d, %t)", rule, n, n, ret)
+		_print("\n   memoize(%d, position%d, tokenIndex%d, reach%d, %t)", rule, n, n, n, ret)
+	}
+	/* a memoized rule keeps track of how far it looks into the input */
+	printReachSave := func(n uint) { _print("\n   reach%d := reach\n   reach = position", n) }
+	/* the input a lookahead matched and gave back has been looked at */
+	printReach := func() {
+		if t.Ast {
+			_print("\n   reach = max(reach, position)")
+		}
+	}
+	/* failures are only expected at the farthest position. Before it,
+	   expect only moves reach past the failure, which a failure before
+	   reach leaves where it is, so expect isn't called for those.
+	   Silenced code, like that of a !, expects nothing */
+	silenced := 0
+	printExpect := func(what string) {
+		switch {
+		case silenced > 0 && t.Ast:
+			_print("\n   reach = max(reach, position+1)")
+		case silenced > 0:
+		case t.Ast:
+			_print("\n   if position >= farthest || position >= reach {\n   expect(%s)\n   }", strconv.Quote(what))
+		default:
+			_print("\n   if position >= farthest {\n   expect(%s)\n   }", strconv.Quote(what))
+		}
 	}
-	printMemoCheck := func(rule int) {
-		_print("\n   if memoized, ok := memoization[memoKey[U]{%d, position}]; ok {", rule)
-		_print("\n       return memoizedResult(memoized)")
+	printGrowBegin := func(rule int, involved []int) {
+		rules := make([]string, len(involved))
+		for i, id := range involved {
+			rules[i] = strconv.Itoa(id)
+		}
+		_print("\n   return growLeftRecursion(%d, []U{%s}, func() bool {", rule, strings.Join(rules, ", "))
+	}
+	printMemoCheck := func(rule *node) {
+		_print("\n   if memoized, ok := memoization.get(memoKey[U]{%d, position}); ok {", rule.GetID())
+		if t.Trace {
+			_print("\n       traceMemo(memoized)")
+		}
+		_print("\n       return memoizedResult(rule%v, memoized)", rule)
 		_print("\n   }")
 	}
 
 	t.HasActions = usage[TypeAction] > 0
-	t.HasPush = usage[TypePush] > 0
+	// named captures have rules of their own
+	_, t.HasPush = t.Rules["PegText"]
+	// pos is only kept up to date for the actions reading it
+	t.HasPosition = slices.ContainsFunc(t.Actions, func(action *node) bool {
+		return mentions(action.String(), "pos")
+	})
 	t.HasCommit = usage[TypeCommit] > 0
 	t.HasDot = usage[TypeDot] > 0
 	t.HasCharacter = usage[TypeCharacter] > 0
 	t.HasString = usage[TypeString] > 0
+	t.HasCaseInsensitive = usage[TypeCaseInsensitive] > 0
+	t.HasProperty = usage[TypeProperty] > 0
 	t.HasRange = usage[TypeRange] > 0
+	t.HasRecovery = usage[TypeRecovery] > 0
+	if t.Ast {
+		t.VisitedRules = t.visitedRules()
+	}
+	if t.TypedAst {
+		if !t.Ast || t.Stream {
+			return errors.New("-typed-ast needs the syntax tree, so it can't be used with -noast or -stream")
+		}
+		t.TypedNodes = t.typedNodes()
+	}
+	for _, name := range t.Captures {
+		if !t.Ast || t.Stream {
+			return errors.New("named captures need the syntax tree, so they can't be used with -noast or -stream")
+		}
+		if hidesActionVariable(name) {
+			return fmt.Errorf("capture '%v' hides a variable of the actions", name)
+		}
+	}
+	if t.HasCaseInsensitive || t.HasProperty {
+		t.Imports = append(t.Imports, "unicode")
+	}
+	slices.Sort(t.Imports)
+	t.Imports = slices.Compact(t.Imports)
+	t.HasLeftRecursion = slices.ContainsFunc(t.leftRecursion, func(parent int) bool { return parent >= 0 })
 
-	var printRule func(n *node)
 	var compile func(expression *node, ko uint) (labelLast bool)
 	var label uint
 	labels := make(map[uint]bool)
+	/* the labels jumped to in the current pass, which with switches can
+	   be fewer than in the dry one */
+	jumped := make(map[uint]bool)
+
+	// commit is where a cut sends failures: the failure label of the
+	// innermost choice and, if the cut releases that choice, the label
+	// of its saved choices counter
+	type commitPoint struct {
+		ko, choices uint
+		counted     bool
+	}
+	var commit commitPoint
+	counting := t.Ast && t.HasCommit
+	printChoice := func(n uint) {
+		if counting {
+			_print("\n   choices%d := choices", n)
+		}
+	}
+	printHold := func(n uint) {
+		if counting {
+			_print("\n   hold(choices%d)", n)
+		}
+	}
+	printRelease := func(n uint) {
+		if counting {
+			_print("\n   choices = choices%d", n)
+		}
+	}
 	printBegin := func() { _print("\n   {") }
 	printEnd := func() { _print("\n   }") }
 	printLabel := func(n uint) bool {
@@ -899,90 +2316,22 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 	}
 	printJump := func(n uint) {
 		_print("\n   goto l%d", n)
-		labels[n] = true
+		labels[n], jumped[n] = true, true
 	}
-	printRule = func(n *node) {
-		switch n.GetType() {
-		case TypeRule:
-			_print("%v <- ", n)
-			printRule(n.Front())
-		case TypeDot:
-			_print(".")
-		case TypeName:
-			_print("%v", n)
-		case TypeCharacter:
-			_print("'%v'", escape(n.String()))
-		case TypeString:
-			s := escape(n.String())
-			_print("'%v'", s[1:len(s)-1])
-		case TypeRange:
-			element := n.Front()
-			lower := element
-			element = element.Next()
-			upper := element
-			_print("[%v-%v]", escape(lower.String()), escape(upper.String()))
-		case TypePredicate:
-			_print("&{%v}", n)
-		case TypeStateChange:
-			_print("!{%v}", n)
-		case TypeAction:
-			_print("{%v}", n)
-		case TypeCommit:
-			_print("commit")
-		case TypeAlternate:
-			_print("(")
-			elements := slices.Collect(n.Iterator())
-			printRule(elements[0])
-			for _, element := range elements[1:] {
-				_print(" / ")
-				printRule(element)
-			}
-			_print(")")
-		case TypeUnorderedAlternate:
-			_print("(")
-			elements := slices.Collect(n.Iterator())
-			printRule(elements[0])
-			for _, element := range elements[1:] {
-				_print(" | ")
-				printRule(element)
-			}
-			_print(")")
-		case TypeSequence:
-			_print("(")
-			elements := slices.Collect(n.Iterator())
-			printRule(elements[0])
-			for _, element := range elements[1:] {
-				_print(" ")
-				printRule(element)
-			}
-			_print(")")
-		case TypePeekFor:
-			_print("&")
-			printRule(n.Front())
-		case TypePeekNot:
-			_print("!")
-			printRule(n.Front())
-		case TypeQuery:
-			printRule(n.Front())
-			_print("?")
-		case TypeStar:
-			printRule(n.Front())
-			_print("*")
-		case TypePlus:
-			printRule(n.Front())
-			_print("+")
-		case TypePush, TypeImplicitPush:
-			_print("<")
-			printRule(n.Front())
-			_print(">")
-		case TypeComment:
-		case TypeNil:
-		default:
-			t.warn(fmt.Errorf("illegal node type: %v", n.GetType()))
-		}
+	/* running out of steps fails rules and repetitions until the parse
+	   has unwound */
+	printStep := func(ko uint) {
+		_print("\n   if limited && !step() {")
+		printJump(ko)
+		_print("}")
 	}
 	dryCompile := true
 
+	/* the symbol at position; parsers over bytes or streams check for the end themselves */
+	symbol := "buffer[position]"
+	if t.Bytes || t.Stream {
+		symbol = "peek()"
+	}
 	compile = func(n *node, ko uint) (labelLast bool) {
 		switch n.GetType() {
 		case TypeRule:
@@ -993,22 +2342,64 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			}
 			_print("\n   if !matchDot() {")
 			/*print("\n   if buffer[position] == endSymbol {")*/
+			printExpect(t.expectation(n))
 			printJump(ko)
 			/*print("}\nposition++")*/
 			_print("}")
 		case TypeName:
 			name := n.String()
 			rule := t.Rules[name]
-			if t.inline && t.rulesCount[name] == 1 {
+			// Failures inside filler rules such as white space are not
+			// reported as expectations
+			filler := isFiller(rule)
+			if t.inlined(name) {
 				element := rule.Front()
 				element.SetParentDetect(n.ParentDetect())
 				element.SetParentMultipleKey(n.ParentMultipleKey())
-				compile(element, ko)
-				return labelLast
+				outer := commit
+				defer func() { commit = outer }()
+				if filler || rule.CheckAlwaysSucceeds(t) {
+					if filler {
+						_print("\n   silent++")
+						silenced++
+					}
+					commit = commitPoint{ko: ko}
+					compile(element, ko)
+					if filler {
+						_print("\n   silent--")
+						silenced--
+					}
+					return labelLast
+				}
+				// The rule is expected where it fails, like a rule that
+				// isn't inlined, rather than what it failed to match
+				rko, rok := label, label+1
+				label += 2
+				printBegin()
+				_print("\n   begin%d, mark%d := position, expectMark()", rko, rko)
+				commit = commitPoint{ko: rko}
+				compile(element, rko)
+				if !jumped[rko] {
+					_print("\n   _, _ = begin%d, mark%d", rko, rko)
+					printEnd()
+					return labelLast
+				}
+				printJump(rok)
+				printLabel(rko)
+				_print("\n   expectRule(rule%v, begin%d, mark%d)", name, rko, rko)
+				printJump(ko)
+				printEnd()
+				return printLabel(rok)
 			}
 			// If the rule always succeeds, do not output the if statement
 			if rule.CheckAlwaysSucceeds(t) {
+				if filler {
+					_print("\n   silent++")
+				}
 				_print("\n   _rules[rule%v]()", name /*rule.GetID()*/)
+				if filler {
+					_print("\n   silent--")
+				}
 			} else {
 				_print("\n   if !_rules[rule%v]() {", name /*rule.GetID()*/)
 				printJump(ko)
@@ -1024,7 +2415,8 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			element = element.Next()
 			upper := element
 			/*print("\n   if !matchRange('%v', '%v') {", escape(lower.String()), escape(upper.String()))*/
-			_print("\n   if c := buffer[position]; c < '%v' || c > '%v' {", escape(lower.String()), escape(upper.String()))
+			_print("\n   if c := %v; c < '%v' || c > '%v' {", symbol, escape(lower.String()), escape(upper.String()))
+			printExpect(t.expectation(n))
 			printJump(ko)
 			_print("}\nposition++")
 		case TypeCharacter:
@@ -1033,13 +2425,30 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 				break
 			}
 			/*print("\n   if !matchChar('%v') {", escape(n.String()))*/
-			_print("\n   if buffer[position] != '%v' {", escape(n.String()))
+			_print("\n   if %v != '%v' {", symbol, escape(n.String()))
+			printExpect(t.expectation(n))
 			printJump(ko)
 			_print("}\nposition++")
 		case TypeString:
 			_print("\n   if !matchString(%v) {", strconv.Quote(n.String()))
+			printExpect(t.expectation(n))
+			printJump(ko)
+			_print("}")
+		case TypeCaseInsensitive:
+			_print("\n   if !matchCaseInsensitive(%v) {", strconv.Quote(n.String()))
+			printExpect(t.expectation(n))
 			printJump(ko)
 			_print("}")
+		case TypeProperty:
+			name, _, negated := property(n)
+			if negated {
+				_print("\n   if c := %v; c == endSymbol || unicode.Is(unicode.%v, c) {", symbol, name)
+			} else {
+				_print("\n   if !unicode.Is(unicode.%v, %v) {", name, symbol)
+			}
+			printExpect(t.expectation(n))
+			printJump(ko)
+			_print("}\nposition++")
 		case TypePredicate:
 			_print("\n   if !(%v) {", n)
 			printJump(ko)
@@ -1048,6 +2457,12 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			_print("\n   %v", n)
 		case TypeAction:
 		case TypeCommit:
+			if counting {
+				if commit.counted {
+					printRelease(commit.choices)
+				}
+				_print("\n   cut()")
+			}
 		case TypePush:
 			fallthrough
 		case TypeImplicitPush:
@@ -1066,6 +2481,11 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 				}
 			} else {
 				_print("\nposition%d := position", ok)
+				if n.GetType() == TypePush && t.Stream && t.Ast && t.HasCommit {
+					// Mark where the capture begins so that a streaming
+					// parser keeps its text until the capture is run
+					_print("\nadd(ruleUnknown, position)")
+				}
 				compile(element, ko)
 				if n.GetType() == TypePush && !t.Ast {
 					// This is TypePush and there is no AST support,
@@ -1073,6 +2493,9 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 					_print("\nbegin := position%d", ok)
 					_print("\nend := position")
 					_print("\ntext = string(buffer[begin:end])")
+					if t.HasPosition {
+						_print("\npos = p.Position(int(begin))")
+					}
 				} else {
 					_print("\nadd(rule%v, position%d)", rule, ok)
 				}
@@ -1086,15 +2509,24 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			elements[0].SetParentDetect(n.ParentDetect())
 			elements[0].SetParentMultipleKey(n.ParentMultipleKey())
 			printSave(ok)
+			printChoice(ok)
+			outer := commit
 			for _, element := range elements[:len(elements)-1] {
 				next := label
 				label++
+				printHold(ok)
+				commit = commitPoint{ko: ko, choices: ok, counted: true}
 				compile(element, next)
+				printRelease(ok)
 				printJump(ok)
 				printLabel(next)
+				printBacktrack(ok)
 				printRestore(ok)
 			}
+			printRelease(ok)
+			commit = commitPoint{ko: ko}
 			compile(elements[len(elements)-1], ko)
+			commit = outer
 			printEnd()
 			labelLast = printLabel(ok)
 		case TypeUnorderedAlternate:
@@ -1102,7 +2534,9 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			ok := label
 			label++
 			printBegin()
-			_print("\n   switch buffer[position] {")
+			_print("\n   switch %v {", symbol)
+			outer := commit
+			commit = commitPoint{ko: done}
 			elements := slices.Collect(n.Iterator())
 			elements, last := elements[:len(elements)-1], elements[len(elements)-1].Front().Next()
 			for _, element := range elements {
@@ -1131,9 +2565,18 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 				}
 			}
 			_print("\n   default:")
+			for _, element := range elements {
+				sequence := element.Front()
+				if what := t.expectation(sequence.Next()); what != "" {
+					printExpect(what)
+				} else {
+					printExpect(describeClass(sequence.Front()))
+				}
+			}
 			if compile(last, done) {
 				_print("\nbreak")
 			}
+			commit = outer
 			_print("\n   }")
 			printEnd()
 			labelLast = printLabel(ok)
@@ -1143,16 +2586,26 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			elements[0].SetParentMultipleKey(n.ParentMultipleKey())
 			for _, element := range elements {
 				labelLast = compile(element, ko)
+				if element.ownsCommit() {
+					ko = commit.ko
+				}
 			}
 		case TypePeekFor:
 			ok := label
 			label++
 			printBegin()
 			printSave(ok)
+			printChoice(ok)
+			printHold(ok)
 			element := n.Front()
 			element.SetParentDetect(n.ParentDetect())
 			element.SetParentMultipleKey(n.ParentMultipleKey())
+			outer := commit
+			commit = commitPoint{ko: ko}
 			compile(element, ko)
+			commit = outer
+			printRelease(ok)
+			printReach()
 			printRestore(ok)
 			printEnd()
 		case TypePeekNot:
@@ -1163,10 +2616,35 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			element := n.Front()
 			element.SetParentDetect(n.ParentDetect())
 			element.SetParentMultipleKey(n.ParentMultipleKey())
+			printChoice(ok)
+			printHold(ok)
+			outer := commit
+			commit = commitPoint{ko: ok}
+			/* only the rules called read silent */
+			calls := t.callsRules(element, make(map[string]bool))
+			if calls {
+				_print("\n   silent++")
+			}
+			silenced++
 			compile(element, ok)
+			silenced--
+			if calls {
+				_print("\n   silent--")
+			}
+			commit = outer
+			printReach()
+			if element.GetType() == TypeDot {
+				printRestore(ok)
+				printExpect("end of input")
+			}
+			printRelease(ok)
 			printJump(ko)
 			printLabel(ok)
+			if calls {
+				_print("\n   silent--")
+			}
 			printRestore(ok)
+			printRelease(ok)
 			printEnd()
 		case TypeQuery:
 			qko := label
@@ -1175,13 +2653,21 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			label++
 			printBegin()
 			printSave(qko)
+			printChoice(qko)
+			printHold(qko)
 			element := n.Front()
 			element.SetParentDetect(n.ParentDetect())
 			element.SetParentMultipleKey(n.ParentMultipleKey())
+			outer := commit
+			commit = commitPoint{ko: ko, choices: qko, counted: true}
 			compile(element, qko)
+			commit = outer
+			printRelease(qko)
 			printJump(qok)
 			printLabel(qko)
+			printBacktrack(qko)
 			printRestore(qko)
+			printRelease(qko)
 			printEnd()
 			labelLast = printLabel(qok)
 		case TypeStar:
@@ -1192,27 +2678,74 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 			printLabel(again)
 			printBegin()
 			printSave(out)
+			printChoice(out)
+			printHold(out)
+			printStep(out)
 			element := n.Front()
 			element.SetParentDetect(n.ParentDetect())
 			element.SetParentMultipleKey(n.ParentMultipleKey())
+			outer := commit
+			commit = commitPoint{ko: ko, choices: out, counted: true}
 			compile(element, out)
+			commit = outer
+			printRelease(out)
 			printJump(again)
 			printLabel(out)
+			printBacktrack(out)
 			printRestore(out)
+			printRelease(out)
 			printEnd()
+		case TypeRecovery:
+			failed := label
+			label++
+			ok := label
+			label++
+			printBegin()
+			printSave(failed)
+			printChoice(failed)
+			printHold(failed)
+			element := n.Front()
+			element.SetParentDetect(n.ParentDetect())
+			element.SetParentMultipleKey(n.ParentMultipleKey())
+			outer := commit
+			commit = commitPoint{ko: failed}
+			compile(element, failed)
+			commit = outer
+			printRelease(failed)
+			printJump(ok)
+			printLabel(failed)
+			printBacktrack(failed)
+			printRestore(failed)
+			printRelease(failed)
+			recovery := element.Next()
+			_print("\n   diagnostic%d := recoverFrom(rule%v)", failed, recovery)
+			compile(recovery, ko)
+			_print("\n   recovered(diagnostic%d)", failed)
+			printEnd()
+			labelLast = printLabel(ok)
 		case TypePlus:
 			again := label
 			label++
 			out := label
 			label++
+			outer := commit
+			commit = commitPoint{ko: ko}
 			compile(n.Front(), ko)
 			printLabel(again)
 			printBegin()
 			printSave(out)
+			printChoice(out)
+			printHold(out)
+			printStep(out)
+			commit = commitPoint{ko: ko, choices: out, counted: true}
 			compile(n.Front(), out)
+			commit = outer
+			printRelease(out)
 			printJump(again)
 			printLabel(out)
+			printBacktrack(out)
 			printRestore(out)
+			printRelease(out)
 			printEnd()
 		case TypeComment:
 		case TypeNil:
@@ -1239,16 +2772,18 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 		}
 		ko := label
 		label++
-		if count, ok := t.rulesCount[element.String()]; !ok {
+		if _, ok := t.rulesCount[element.String()]; !ok {
 			continue
-		} else if t.inline && count == 1 && ko != 0 {
+		} else if t.inlined(element.String()) && ko != 0 {
 			continue
 		}
+		commit = commitPoint{ko: ko}
 		compile(expression, ko)
 	}
 	_print = printTemp
 	label = 0
 	dryCompile = false
+	clear(jumped)
 
 	/* now for the real compile pass */
 	t.PegRuleType = "uint8"
@@ -1276,7 +2811,7 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 		}
 		expression := element.Front()
 		if implicit := expression.Front(); expression.GetType() == TypeNil || implicit.GetType() == TypeNil {
-			if element.String() != "PegText" {
+			if element.String() != "PegText" && !strings.HasPrefix(element.String(), "PegText_") {
 				t.warn(fmt.Errorf("rule '%v' used but not defined", element))
 			}
 			_print("\n  nil,")
@@ -1285,40 +2820,59 @@ func (t *Tree) Compile(file string, args []string, out io.Writer) (err error) {
 		ko := label
 		label++
 		_print("\n  /* %v ", element.GetID())
-		printRule(element)
+		t.printRule(&buffer, element)
 		_print(" */")
-		if count, ok := t.rulesCount[element.String()]; !ok {
+		if _, ok := t.rulesCount[element.String()]; !ok {
 			t.warn(fmt.Errorf("rule '%v' defined but not used", element))
 			_print("\n  nil,")
 			continue
-		} else if t.inline && count == 1 && ko != 0 {
+		} else if t.inlined(element.String()) && ko != 0 {
 			_print("\n  nil,")
 			continue
 		}
 		_print("\n  func() bool {")
-		if t.Ast {
-			printMemoCheck(element.GetID())
+		_print("\n   if limited && !step() {\n   return false\n   }")
+		recursive, involved := t.leftRecursive(element.GetID())
+		memoized := memoizing[element.String()] && !recursive
+		if recursive {
+			printGrowBegin(element.GetID(), involved)
+		} else if memoized {
+			printMemoCheck(element)
 		}
-		if t.Ast || labels[ko] {
+		if memoized || labels[ko] {
 			printSave(ko)
 		}
+		if memoized {
+			printReachSave(ko)
+		}
+		if labels[ko] {
+			_print("\n   mark%d := expectMark()", ko)
+		}
+		commit = commitPoint{ko: ko}
 		compile(expression, ko)
-		// print("\n  fmt.Printf(\"%v\\n\")", element.String())
-		if t.Ast {
+		if memoized {
 			printMemoSave(element.GetID(), uint64(ko), true)
 		}
 		_print("\n   return true")
 		if labels[ko] {
 			printLabel(ko)
-			if t.Ast {
+			_print("\n   expectRule(rule%v, position%d, mark%d)", element, ko, ko)
+			if memoized {
 				printMemoSave(element.GetID(), uint64(ko), false)
 			}
 			printRestore(ko)
 			_print("\n   return false")
 		}
+		if recursive {
+			_print("\n   })")
+		}
 		_print("\n  },")
 	}
-	_print("\n }\n p.rules = _rules")
+	_print("\n }\n limitDepth()\n profileRules()")
+	if t.Trace {
+		_print("\n traceRules()")
+	}
+	_print("\n p.rules = _rules")
 	_print("\n return nil")
 	_print("\n}\n")
 